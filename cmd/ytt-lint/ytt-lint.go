@@ -20,16 +20,39 @@ var linter yttlint.Linter
 var file, rootFolder string
 var excludeList []string
 
+// stringArray is a flag which may be specified multiple times
+type stringArray []string
+
+func (a *stringArray) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *stringArray) Set(value string) error {
+	*a = append(*a, value)
+	return nil
+}
+
 func main() {
-	var pedantic, pullFromK8S, autoImport bool
+	var pedantic, pullFromK8S, autoImport, project bool
 	var pullKubeconfig, pullContext string
+	var dataValuesOpts yttlint.DataValuesOpts
+	var profileNames, disabled []string
 	flag.StringVar(&file, "f", "-", "File to validate")
-	flag.StringVar(&rootFolder, "root", "", "Root folder for validation (defaults to directory containing target file)")
+	flag.StringVar(&rootFolder, "root", "", "Root folder for validation (defaults to the closest folder containing .ytt-lint/ignore or .ytt-lint/profiles, the working directory if it contains the target file or the directory containing the target file)")
 	flag.BoolVar(&pedantic, "p", false, "Use pedantic linting mode")
+	flag.BoolVar(&project, "project", false, "Lint the output of all templates after applying the overlays among them, just like ytt renders them")
 	flag.BoolVar(&autoImport, "autoimport", false, "Automatically import schema of every custom resource defintion found during linting")
 	flag.BoolVar(&pullFromK8S, "pull-from-k8s", false, "Pull crd schemas from Kubernetes cluster")
 	flag.StringVar(&pullKubeconfig, "kubeconfig", "", "path to kubeconfig (used only for --pull-from-k8s)")
 	flag.StringVar(&pullContext, "context", "", "context inside kubeconfig (used only for --pull-from-k8s)")
+	flag.Var((*stringArray)(&dataValuesOpts.PlainFiles), "data-values-file", "Use all documents of the given yaml file as data values (can be specified multiple times)")
+	flag.Var((*stringArray)(&dataValuesOpts.KVsFromStrings), "data-value", "Set specific data value to given value, as string (format: all.key1.subkey=123) (can be specified multiple times)")
+	flag.Var((*stringArray)(&dataValuesOpts.KVsFromStrings), "v", "Shorthand for --data-value")
+	flag.Var((*stringArray)(&dataValuesOpts.KVsFromYAML), "data-value-yaml", "Set specific data value to given value, parsed as YAML (format: all.key1.subkey=true) (can be specified multiple times)")
+	flag.Var((*stringArray)(&dataValuesOpts.EnvFromStrings), "data-values-env", "Extract data values (as strings) from prefixed env vars (format: PREFIX for PREFIX_all__key1=str) (can be specified multiple times)")
+	flag.Var((*stringArray)(&dataValuesOpts.EnvFromYAML), "data-values-env-yaml", "Extract data values (parsed as YAML) from prefixed env vars (format: PREFIX for PREFIX_all__key1=true) (can be specified multiple times)")
+	flag.Var((*stringArray)(&disabled), "disable", "Do not report errors with the given code, e.g. UNUSED_LOAD (can be specified multiple times)")
+	flag.Var((*stringArray)(&profileNames), "profile", "Lint with the data values of the given profile, either a name of a file in .ytt-lint/profiles or a path (can be specified multiple times, defaults to all profiles)")
 	outputFormat := flag.String("o", "human", "Output format: either human or json")
 	flag.Parse()
 
@@ -50,10 +73,6 @@ func main() {
 
 	}
 
-	linter = yttlint.Linter{
-		Pedantic: pedantic,
-	}
-
 	errors := []yttlint.LinterError{}
 
 	stdin := false
@@ -65,6 +84,39 @@ func main() {
 		stdin = true
 	}
 
+	root := ""
+	if file != "-" || rootFolder != "" {
+		root = getRootFolder()
+		dataValuesOpts.Files, err = yttlint.FindDataValuesFiles(root, isFileExcluded)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	dataValues, err := yttlint.LoadDataValues(dataValuesOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Loading data values: %v\n", err)
+		os.Exit(1)
+	}
+	profiles, err := yttlint.LoadProfiles(root, profileNames, dataValuesOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	linter = yttlint.Linter{
+		Pedantic:   pedantic,
+		DataValues: dataValues,
+		Profiles:   profiles,
+		Root:       root,
+	}
+	if root != "" {
+		linter.IsExcluded = isFileExcluded
+	}
+	for _, code := range disabled {
+		linter.Disabled = append(linter.Disabled, yttlint.ErrorCode(code))
+	}
+
 	if file != "-" && isEntryFileExclude() {
 		fmt.Fprintf(os.Stderr, "Warning '%s' is excluded. Won't lint anything\n", file)
 		formatter.Format(os.Stdout, errors)
@@ -74,15 +126,24 @@ func main() {
 	if stdin {
 		errors = lintReader(os.Stdin, file, autoImport)
 	} else {
+		projectFiles := []string{}
 		err := filepath.Walk(file, func(path string, info os.FileInfo, _ error) error {
 			if isFileExcluded(path) {
 				return filepath.SkipDir
 			}
 
 			if info.IsDir() {
+				if project && (info.Name() == ".ytt-lint" || info.Name() == "_ytt_lib") {
+					return filepath.SkipDir
+				}
 				return nil
 			}
-			if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") && file != path {
+			if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") && !strings.HasSuffix(path, ".star") && file != path {
+				return nil
+			}
+			// starlark modules are no output of ytt, they are linted on their own
+			if project && !strings.HasSuffix(path, ".star") {
+				projectFiles = append(projectFiles, path)
 				return nil
 			}
 
@@ -102,7 +163,12 @@ func main() {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			os.Exit(1)
 		}
+		if project && len(projectFiles) > 0 {
+			errors = append(errors, linter.LintProject(projectFiles, autoImport)...)
+		}
 
+		// a single file from stdin usually does not use all data values
+		errors = append(errors, linter.UnusedDataValues()...)
 	}
 
 	formatter.Format(os.Stdout, errors)
@@ -126,9 +192,9 @@ func getRootFolder() string {
 			os.Exit(1)
 		}
 		if stat.IsDir() {
-			rootFolder = file
+			rootFolder = yttlint.FindProjectRoot(file)
 		} else {
-			rootFolder = filepath.Dir(file)
+			rootFolder = yttlint.FindProjectRoot(filepath.Dir(file))
 		}
 	}
 	return rootFolder
@@ -139,21 +205,23 @@ func isEntryFileExclude() bool {
 	f := file
 
 	for {
-		isExcluded := isFileExcluded(f)
-		if isExcluded {
-			return true
-		}
-
-		f = filepath.Dir(f)
 		rel, err := filepath.Rel(root, f)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			os.Exit(1)
 		}
 
+		// the root folder itself is never excluded
 		if rel == "." {
 			return false
 		}
+
+		isExcluded := isFileExcluded(f)
+		if isExcluded {
+			return true
+		}
+
+		f = filepath.Dir(f)
 	}
 
 }
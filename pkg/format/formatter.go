@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/SAP/ytt-lint/pkg/yttlint"
 	"github.com/pkg/errors"
@@ -53,7 +54,11 @@ func (*humanFormatter) Format(writer io.Writer, lintErrors []yttlint.LinterError
 		fmt.Fprintln(writer, "No errors found")
 	} else {
 		for _, err := range lintErrors {
-			fmt.Fprintf(writer, "error: %s @ %s\n", err.Msg, err.Pos)
+			if len(err.Profiles) > 0 {
+				fmt.Fprintf(writer, "error: %s @ %s (profiles: %s)\n", err.Msg, err.Pos, strings.Join(err.Profiles, ", "))
+			} else {
+				fmt.Fprintf(writer, "error: %s @ %s\n", err.Msg, err.Pos)
+			}
 		}
 	}
 	_, err := fmt.Fprintln(writer)
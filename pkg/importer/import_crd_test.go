@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestImportV1KeepsValidationRules(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	schemaDir, err := ioutil.TempDir("", "ytt-lint-schema")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(schemaDir)
+
+	importer := &importerImpl{schemaDir: schemaDir}
+	crd := apiextensionsv1.CustomResourceDefinition{}
+	g.Expect(yaml.Unmarshal([]byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        x-kubernetes-validations:
+        - rule: self.minReplicas <= self.maxReplicas
+          message: minReplicas must not exceed maxReplicas
+        properties:
+          ports:
+            type: array
+            items:
+              type: object
+              x-kubernetes-validations:
+              - rule: self.port > 0
+`), &crd)).To(Succeed())
+	g.Expect(importer.ImportV1(crd)).To(Succeed())
+
+	data, err := ioutil.ReadFile(path.Join(schemaDir, "example.com", "v1", "widget.json"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	schema := map[string]interface{}{}
+	g.Expect(json.Unmarshal(data, &schema)).To(Succeed())
+	g.Expect(schema).To(HaveKeyWithValue("x-kubernetes-validations", []interface{}{map[string]interface{}{
+		"rule":    "self.minReplicas <= self.maxReplicas",
+		"message": "minReplicas must not exceed maxReplicas",
+	}}))
+	properties := schema["properties"].(map[string]interface{})
+	items := properties["ports"].(map[string]interface{})["items"].(map[string]interface{})
+	g.Expect(items).To(HaveKeyWithValue("x-kubernetes-validations", []interface{}{map[string]interface{}{
+		"rule": "self.port > 0",
+	}}))
+	g.Expect(properties).To(HaveKey("metadata"))
+}
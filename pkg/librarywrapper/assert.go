@@ -0,0 +1,41 @@
+package librarywrapper
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+var (
+	AssertAPIWrapper = starlark.StringDict{
+		"assert": &starlarkstruct.Module{
+			Name: "assert",
+			Members: starlark.StringDict{
+				"fail": starlark.NewBuiltin("assert.fail", assertModule{}.Fail),
+			},
+		},
+	}
+	assertmodule = yttlibrary.AssertAPI["assert"].(*starlarkstruct.Module)
+)
+
+func init() {
+	yttlibrary.AssertAPI = AssertAPIWrapper
+}
+
+type assertModule struct{}
+
+// Fail still fails for computed messages, but without complaining about the type of the message. Errors of the
+// original function are returned as is, it prefixes them with the name of the builtin already.
+func (b assertModule) Fail(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if args.Len() == 1 {
+		if _, ok := args.Index(0).(*magic.MagicType); ok {
+			return starlark.None, fmt.Errorf("%s: fail: (computed message)", f.Name())
+		}
+	}
+
+	return assertmodule.Members["fail"].(*starlark.Builtin).CallInternal(thread, args, kwargs)
+}
@@ -0,0 +1,26 @@
+package librarywrapper
+
+import (
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+var (
+	JSONAPIWrapper = starlark.StringDict{
+		"json": &starlarkstruct.Module{
+			Name: "json",
+			Members: starlark.StringDict{
+				"encode": computedResult(jsonmodule, "encode", &magic.MagicType{CouldBeString: true}),
+				"decode": computedResult(jsonmodule, "decode", decodedValue),
+			},
+		},
+	}
+	jsonmodule = yttlibrary.JSONAPI["json"].(*starlarkstruct.Module)
+)
+
+func init() {
+	yttlibrary.JSONAPI = JSONAPIWrapper
+}
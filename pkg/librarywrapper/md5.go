@@ -0,0 +1,25 @@
+package librarywrapper
+
+import (
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+var (
+	MD5APIWrapper = starlark.StringDict{
+		"md5": &starlarkstruct.Module{
+			Name: "md5",
+			Members: starlark.StringDict{
+				"sum": computedResult(md5module, "sum", &magic.MagicType{CouldBeString: true}),
+			},
+		},
+	}
+	md5module = yttlibrary.MD5API["md5"].(*starlarkstruct.Module)
+)
+
+func init() {
+	yttlibrary.MD5API = MD5APIWrapper
+}
@@ -0,0 +1,25 @@
+package librarywrapper
+
+import (
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+var (
+	RegexpAPIWrapper = starlark.StringDict{
+		"regexp": &starlarkstruct.Module{
+			Name: "regexp",
+			Members: starlark.StringDict{
+				"match": computedResult(regexpmodule, "match", &magic.MagicType{CouldBeBool: true}),
+			},
+		},
+	}
+	regexpmodule = yttlibrary.RegexpAPI["regexp"].(*starlarkstruct.Module)
+)
+
+func init() {
+	yttlibrary.RegexpAPI = RegexpAPIWrapper
+}
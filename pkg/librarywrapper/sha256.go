@@ -0,0 +1,25 @@
+package librarywrapper
+
+import (
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+var (
+	SHA256APIWrapper = starlark.StringDict{
+		"sha256": &starlarkstruct.Module{
+			Name: "sha256",
+			Members: starlark.StringDict{
+				"sum": computedResult(sha256module, "sum", &magic.MagicType{CouldBeString: true}),
+			},
+		},
+	}
+	sha256module = yttlibrary.SHA256API["sha256"].(*starlarkstruct.Module)
+)
+
+func init() {
+	yttlibrary.SHA256API = SHA256APIWrapper
+}
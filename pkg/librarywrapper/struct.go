@@ -0,0 +1,70 @@
+package librarywrapper
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/template/core"
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+var (
+	StructAPIWrapper = starlark.StringDict{
+		"struct": &starlarkstruct.Module{
+			Name: "struct",
+			Members: starlark.StringDict{
+				"make":          structmodule.Members["make"],
+				"make_and_bind": structmodule.Members["make_and_bind"],
+				"bind":          structmodule.Members["bind"],
+
+				"encode": starlark.NewBuiltin("struct.encode", core.ErrWrapper(structModule{}.Encode)),
+				"decode": starlark.NewBuiltin("struct.decode", core.ErrWrapper(structModule{}.Decode)),
+			},
+		},
+	}
+	structmodule = yttlibrary.StructAPI["struct"].(*starlarkstruct.Module)
+)
+
+func init() {
+	yttlibrary.StructAPI = StructAPIWrapper
+}
+
+type structModule struct{}
+
+// Encode turns computed dicts into computed structs, computed values nested in other values are kept by the
+// original function
+func (b structModule) Encode(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if args.Len() != 1 {
+		return starlark.None, fmt.Errorf("expected exactly one argument")
+	}
+
+	computed, ok := args.Index(0).(*magic.MagicType)
+	if ok {
+		if !computed.CouldBeDict {
+			return computed, nil
+		}
+		return computed.Without(magic.MagicType{CouldBeDict: true}).Join(&magic.MagicType{CouldBeStruct: true}), nil
+	}
+
+	return starlark.Call(thread, structmodule.Members["encode"], args, kwargs)
+}
+
+// Decode turns computed structs into computed dicts
+func (b structModule) Decode(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if args.Len() != 1 {
+		return starlark.None, fmt.Errorf("expected exactly one argument")
+	}
+
+	computed, ok := args.Index(0).(*magic.MagicType)
+	if ok {
+		if !computed.CouldBeStruct {
+			return computed, nil
+		}
+		return computed.Without(magic.MagicType{CouldBeStruct: true}).Join(magic.DictOf(nil)), nil
+	}
+
+	return starlark.Call(thread, structmodule.Members["decode"], args, kwargs)
+}
@@ -0,0 +1,32 @@
+package librarywrapper
+
+import (
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+var (
+	URLAPIWrapper = starlark.StringDict{
+		"url": &starlarkstruct.Module{
+			Name: "url",
+			Members: starlark.StringDict{
+				"path_segment_encode": computedResult(urlmodule, "path_segment_encode", &magic.MagicType{CouldBeString: true}),
+				"path_segment_decode": computedResult(urlmodule, "path_segment_decode", &magic.MagicType{CouldBeString: true}),
+
+				"query_param_value_encode": computedResult(urlmodule, "query_param_value_encode", &magic.MagicType{CouldBeString: true}),
+				"query_param_value_decode": computedResult(urlmodule, "query_param_value_decode", &magic.MagicType{CouldBeString: true}),
+
+				"query_params_encode": computedResult(urlmodule, "query_params_encode", &magic.MagicType{CouldBeString: true}),
+				"query_params_decode": computedResult(urlmodule, "query_params_decode", magic.DictOf(magic.ListOf(&magic.MagicType{CouldBeString: true}))),
+			},
+		},
+	}
+	urlmodule = yttlibrary.URLAPI["url"].(*starlarkstruct.Module)
+)
+
+func init() {
+	yttlibrary.URLAPI = URLAPIWrapper
+}
@@ -0,0 +1,25 @@
+package librarywrapper
+
+import (
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+var (
+	VersionAPIWrapper = starlark.StringDict{
+		"version": &starlarkstruct.Module{
+			Name: "version",
+			Members: starlark.StringDict{
+				"require_at_least": computedResult(versionmodule, "require_at_least", &magic.MagicType{CouldBeNone: true}),
+			},
+		},
+	}
+	versionmodule = yttlibrary.VersionAPI["version"].(*starlarkstruct.Module)
+)
+
+func init() {
+	yttlibrary.VersionAPI = VersionAPIWrapper
+}
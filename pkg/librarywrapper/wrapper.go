@@ -0,0 +1,101 @@
+package librarywrapper
+
+import (
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/template/core"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+// computedResult wraps the function name of module. If any argument is or contains a computed value, the wrapper
+// returns a value of type result, otherwise the original function is called.
+func computedResult(module *starlarkstruct.Module, name string, result *magic.MagicType) *starlark.Builtin {
+	return starlark.NewBuiltin(module.Name+"."+name, func(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if hasComputedArgs(args, kwargs) {
+			return result, nil
+		}
+		return starlark.Call(thread, module.Members[name], args, kwargs)
+	})
+}
+
+func hasComputedArgs(args starlark.Tuple, kwargs []starlark.Tuple) bool {
+	for _, arg := range args {
+		if containsMagic(arg) {
+			return true
+		}
+	}
+	for _, kwarg := range kwargs {
+		if containsMagic(kwarg[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsMagic walks a starlark value, so that computed values nested in lists, dicts, structs or yaml fragments are
+// found as well. Values which cannot be converted, e.g. functions, are left to the original function to complain about.
+func containsMagic(value starlark.Value) bool {
+	switch typedVal := value.(type) {
+	case *magic.MagicType:
+		return true
+	case *starlark.Dict:
+		for _, item := range typedVal.Items() {
+			if containsMagic(item[0]) || containsMagic(item[1]) {
+				return true
+			}
+		}
+	case *starlark.List, starlark.Tuple, *starlark.Set:
+		iter := typedVal.(starlark.Iterable).Iterate()
+		defer iter.Done()
+		var item starlark.Value
+		for iter.Next(&item) {
+			if containsMagic(item) {
+				return true
+			}
+		}
+	case *magic.Struct, *core.StarlarkStruct, *starlarkstruct.Struct:
+		attrs := typedVal.(starlark.HasAttrs)
+		for _, name := range attrs.AttrNames() {
+			attr, err := attrs.Attr(name)
+			if err == nil && containsMagic(attr) {
+				return true
+			}
+		}
+	case core.StarlarkValueToGoValueConversion:
+		return containsMagicGoValue(typedVal.AsGoValue())
+	}
+	return false
+}
+
+// containsMagicGoValue checks the go representation of a value, i.e. the nodes of a yaml fragment
+func containsMagicGoValue(value interface{}) bool {
+	switch typedVal := value.(type) {
+	case *magic.MagicType:
+		return true
+	case *orderedmap.Map:
+		found := false
+		typedVal.Iterate(func(key, val interface{}) {
+			found = found || containsMagicGoValue(key) || containsMagicGoValue(val)
+		})
+		return found
+	case []interface{}:
+		for _, item := range typedVal {
+			if containsMagicGoValue(item) {
+				return true
+			}
+		}
+	case yamlmeta.Node:
+		for _, item := range typedVal.GetValues() {
+			if containsMagicGoValue(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodedValue is the type of a computed value after deserialization
+var decodedValue = magic.Any().Without(magic.MagicType{CouldBeStruct: true})
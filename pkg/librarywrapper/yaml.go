@@ -0,0 +1,26 @@
+package librarywrapper
+
+import (
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+var (
+	YAMLAPIWrapper = starlark.StringDict{
+		"yaml": &starlarkstruct.Module{
+			Name: "yaml",
+			Members: starlark.StringDict{
+				"encode": computedResult(yamlmodule, "encode", &magic.MagicType{CouldBeString: true}),
+				"decode": computedResult(yamlmodule, "decode", decodedValue),
+			},
+		},
+	}
+	yamlmodule = yttlibrary.YAMLAPI["yaml"].(*starlarkstruct.Module)
+)
+
+func init() {
+	yttlibrary.YAMLAPI = YAMLAPIWrapper
+}
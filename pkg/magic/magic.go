@@ -1,30 +1,281 @@
 package magic
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/k14s/ytt/pkg/template/core"
 	"go.starlark.net/starlark"
 	"go.starlark.net/syntax"
 )
 
+// MagicType is a value, which is only known when the template is rendered (e.g. a data value). The CouldBe fields
+// describe which types the value might have.
 type MagicType struct {
 	CouldBeString bool
 	CouldBeInt    bool
 	CouldBeFloat  bool
+	CouldBeBool   bool
+	CouldBeNone   bool
+	CouldBeList   bool
+	CouldBeDict   bool
+	CouldBeStruct bool
+	// Elem is the type of the items of a list or the values of a dict, nil means it could be anything
+	Elem *MagicType `json:",omitempty"`
 }
 
-func (mt *MagicType) Freeze() {
-}
-
-func (mt *MagicType) Attr(name string) (starlark.Value, error) {
+// Any creates a MagicType, which could be anything
+func Any() *MagicType {
 	return &MagicType{
 		CouldBeString: true,
 		CouldBeInt:    true,
 		CouldBeFloat:  true,
-	}, nil
+		CouldBeBool:   true,
+		CouldBeNone:   true,
+		CouldBeList:   true,
+		CouldBeDict:   true,
+		CouldBeStruct: true,
+	}
+}
+
+// ListOf creates a MagicType, which is a list with items of type elem
+func ListOf(elem *MagicType) *MagicType {
+	return &MagicType{CouldBeList: true, Elem: elem}
+}
+
+// DictOf creates a MagicType, which is a dict with values of type elem
+func DictOf(elem *MagicType) *MagicType {
+	return &MagicType{CouldBeDict: true, Elem: elem}
+}
+
+// Of returns the type of a starlark value
+func Of(value starlark.Value) *MagicType {
+	switch typedVal := value.(type) {
+	case *MagicType:
+		return typedVal
+	case starlark.String:
+		return &MagicType{CouldBeString: true}
+	case starlark.Int:
+		return &MagicType{CouldBeInt: true}
+	case starlark.Float:
+		return &MagicType{CouldBeFloat: true}
+	case starlark.Bool:
+		return &MagicType{CouldBeBool: true}
+	case starlark.NoneType:
+		return &MagicType{CouldBeNone: true}
+	case *starlark.List, starlark.Tuple:
+		return ListOf(joinAll(typedVal.(starlark.Iterable)))
+	case *starlark.Dict:
+		values := []starlark.Value{}
+		for _, item := range typedVal.Items() {
+			values = append(values, item[1])
+		}
+		return DictOf(joinAll(starlark.NewList(values)))
+	case starlark.HasAttrs:
+		return &MagicType{CouldBeStruct: true}
+	}
+	return Any()
+}
+
+// joinAll joins the types of all items, nil stands for an empty iterable since its items could be anything
+func joinAll(iterable starlark.Iterable) *MagicType {
+	var result *MagicType
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		if result == nil {
+			result = Of(item)
+		} else {
+			result = result.Join(Of(item))
+		}
+	}
+	return result
+}
+
+// kind is a bit set of the types a MagicType could be
+type kind uint8
+
+const (
+	kindString kind = 1 << iota
+	kindInt
+	kindFloat
+	kindBool
+	kindNone
+	kindList
+	kindDict
+	kindStruct
+
+	kindNumber = kindInt | kindFloat
+)
+
+var kindNames = []string{"string", "int", "float", "bool", "None", "list", "dict", "struct"}
+
+func (mt *MagicType) kinds() kind {
+	var result kind
+	for i, set := range []bool{mt.CouldBeString, mt.CouldBeInt, mt.CouldBeFloat, mt.CouldBeBool, mt.CouldBeNone,
+		mt.CouldBeList, mt.CouldBeDict, mt.CouldBeStruct} {
+		if set {
+			result |= 1 << uint(i)
+		}
+	}
+	return result
+}
+
+func fromKinds(k kind, elem *MagicType) *MagicType {
+	result := &MagicType{
+		CouldBeString: k&kindString != 0,
+		CouldBeInt:    k&kindInt != 0,
+		CouldBeFloat:  k&kindFloat != 0,
+		CouldBeBool:   k&kindBool != 0,
+		CouldBeNone:   k&kindNone != 0,
+		CouldBeList:   k&kindList != 0,
+		CouldBeDict:   k&kindDict != 0,
+		CouldBeStruct: k&kindStruct != 0,
+	}
+	if k&(kindList|kindDict) != 0 {
+		result.Elem = elem
+	}
+	return result
+}
+
+// Join returns a MagicType, which could be any of the types mt or other could be
+func (mt *MagicType) Join(other *MagicType) *MagicType {
+	return fromKinds(mt.kinds()|other.kinds(), joinElem(mt, other))
+}
+
+// joinElem joins the element types of all values which could be lists or dicts
+func joinElem(values ...*MagicType) *MagicType {
+	var result *MagicType
+	for _, value := range values {
+		if value.kinds()&(kindList|kindDict) == 0 {
+			continue
+		}
+		if value.Elem == nil {
+			return nil
+		}
+		if result == nil {
+			result = value.Elem
+		} else {
+			result = result.Join(value.Elem)
+		}
+	}
+	return result
+}
+
+// CouldBe reports whether mt might be of one of the types set in other
+func (mt *MagicType) CouldBe(other MagicType) bool {
+	return mt.kinds()&other.kinds() != 0
+}
+
+// CouldOnlyBe reports whether every type mt might be is set in other
+func (mt *MagicType) CouldOnlyBe(other MagicType) bool {
+	return mt.kinds()&^other.kinds() == 0
+}
+
+// Without returns a MagicType, which could be any type of mt except the ones set in other
+func (mt *MagicType) Without(other MagicType) *MagicType {
+	return fromKinds(mt.kinds()&^other.kinds(), mt.Elem)
+}
+
+// Describe lists the types mt might be, e.g. "int or float"
+func (mt *MagicType) Describe() string {
+	k := mt.kinds()
+	if k == Any().kinds() {
+		return "value"
+	}
+	names := []string{}
+	for i, name := range kindNames {
+		if k&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "value"
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+func (mt *MagicType) Freeze() {
 }
 
+// stringMethods are the results of the methods of strings, which are known without knowing the string
+var stringMethods = map[string]*MagicType{
+	"capitalize": {CouldBeString: true},
+	"format":     {CouldBeString: true},
+	"join":       {CouldBeString: true},
+	"lower":      {CouldBeString: true},
+	"lstrip":     {CouldBeString: true},
+	"replace":    {CouldBeString: true},
+	"rstrip":     {CouldBeString: true},
+	"strip":      {CouldBeString: true},
+	"title":      {CouldBeString: true},
+	"upper":      {CouldBeString: true},
+	"split":      ListOf(&MagicType{CouldBeString: true}),
+	"rsplit":     ListOf(&MagicType{CouldBeString: true}),
+	"splitlines": ListOf(&MagicType{CouldBeString: true}),
+	"startswith": {CouldBeBool: true},
+	"endswith":   {CouldBeBool: true},
+	"isalnum":    {CouldBeBool: true},
+	"isalpha":    {CouldBeBool: true},
+	"isdigit":    {CouldBeBool: true},
+	"islower":    {CouldBeBool: true},
+	"isspace":    {CouldBeBool: true},
+	"istitle":    {CouldBeBool: true},
+	"isupper":    {CouldBeBool: true},
+	"count":      {CouldBeInt: true},
+	"find":       {CouldBeInt: true},
+	"index":      {CouldBeInt: true},
+	"rfind":      {CouldBeInt: true},
+	"rindex":     {CouldBeInt: true},
+}
+
+// dictMethods are the methods of dicts, whose results are known from the type of their values
+var dictMethods = []string{"get", "items", "keys", "values"}
+
+func (mt *MagicType) Attr(name string) (starlark.Value, error) {
+	var result *MagicType
+	switch {
+	case mt.CouldOnlyBe(MagicType{CouldBeString: true}):
+		result = stringMethods[name]
+	case mt.CouldOnlyBe(MagicType{CouldBeDict: true}):
+		switch name {
+		case "get":
+			result = mt.elem().Join(&MagicType{CouldBeNone: true})
+		case "keys":
+			result = ListOf(nil)
+		case "values":
+			result = ListOf(mt.Elem)
+		case "items":
+			result = ListOf(ListOf(nil))
+		}
+	}
+	if result == nil {
+		return Any(), nil
+	}
+	return starlark.NewBuiltin(name, func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+		return result, nil
+	}), nil
+}
+
+// AttrNames lists the methods of the types mt might be, which are known without knowing the value. The fields of
+// structs are unknown, so they are not listed.
 func (mt *MagicType) AttrNames() []string {
-	return []string{"data"}
+	names := []string{}
+	if mt.CouldBeString {
+		for name := range stringMethods {
+			names = append(names, name)
+		}
+	}
+	if mt.CouldBeDict {
+		names = append(names, dictMethods...)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (mt *MagicType) String() string {
@@ -36,8 +287,10 @@ func (mt *MagicType) Type() string {
 func (mt *MagicType) Hash() (uint32, error) {
 	return 1, nil
 }
+
+// Truth is only known for None, all other values are assumed to be true
 func (mt *MagicType) Truth() starlark.Bool {
-	return starlark.True
+	return starlark.Bool(!mt.CouldOnlyBe(MagicType{CouldBeNone: true}))
 }
 
 var _ starlark.HasAttrs = &MagicType{}
@@ -48,63 +301,142 @@ func (mt *MagicType) Name() string {
 
 func (mt *MagicType) CallInternal(thread *starlark.Thread, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	//fmt.Println("CallInternal")
-	return &MagicType{
-		CouldBeString: true,
-		CouldBeInt:    true,
-		CouldBeFloat:  true,
-	}, nil
+	return Any(), nil
 }
 
 var _ starlark.Callable = &MagicType{}
 
+// elem is the type of a single item of mt
+func (mt *MagicType) elem() *MagicType {
+	if mt.CouldOnlyBe(MagicType{CouldBeString: true}) {
+		return &MagicType{CouldBeString: true}
+	}
+	if mt.CouldOnlyBe(MagicType{CouldBeList: true, CouldBeDict: true}) && mt.Elem != nil {
+		return mt.Elem
+	}
+	return Any()
+}
+
 func (mt *MagicType) Iterate() starlark.Iterator {
-	return starlark.NewList([]starlark.Value{&MagicType{}}).Iterate()
+	item := Any()
+	if mt.CouldOnlyBe(MagicType{CouldBeList: true}) && mt.Elem != nil {
+		item = mt.Elem
+	}
+	return starlark.NewList([]starlark.Value{item}).Iterate()
 }
 
 var _ starlark.Iterable = &MagicType{}
 
+// Len is consistent with Iterate, which yields exactly one element
 func (mt *MagicType) Len() int {
-	return 42
+	return 1
 }
 
 var _ starlark.Sequence = &MagicType{}
 
+// Get makes indexing with keys possible, every key is assumed to exist
+func (mt *MagicType) Get(key starlark.Value) (starlark.Value, bool, error) {
+	return mt.elem(), true, nil
+}
+
+var _ starlark.Mapping = &MagicType{}
+
 var _ starlark.HasBinary = &MagicType{}
 
+// Binary computes the type of the result for every combination of types both operands could be. If no
+// combination is valid, the operation is left unhandled, so starlark reports it.
 func (mt *MagicType) Binary(op syntax.Token, y starlark.Value, side starlark.Side) (starlark.Value, error) {
-	_, isString := y.(starlark.String)
+	x, z := mt, Of(y)
+	if side == starlark.Right {
+		x, z = z, x
+	}
 
-	if op == syntax.PLUS && isString { // concatenating any value with a string results in a string
-		return &MagicType{
-			CouldBeString: true,
-			CouldBeInt:    false,
-			CouldBeFloat:  false,
-		}, nil
+	var result kind
+	for i := range kindNames {
+		for j := range kindNames {
+			xKind, zKind := kind(1<<uint(i)), kind(1<<uint(j))
+			if x.kinds()&xKind != 0 && z.kinds()&zKind != 0 {
+				result |= binaryKind(op, xKind, zKind)
+			}
+		}
 	}
+	if result == 0 {
+		return nil, nil
+	}
+	return fromKinds(result, joinElem(x, z)), nil
+}
 
-	return &MagicType{
-		CouldBeString: true,
-		CouldBeInt:    true,
-		CouldBeFloat:  true,
-	}, nil
+// binaryKind is the type of the result of x op y, 0 if the operation is not supported for these types
+func binaryKind(op syntax.Token, x, y kind) kind {
+	switch op {
+	case syntax.IN, syntax.NOT_IN:
+		return kindBool
+	case syntax.PLUS:
+		if x == y && x&(kindString|kindList) != 0 {
+			return x
+		}
+		return numberKind(x, y)
+	case syntax.MINUS, syntax.SLASHSLASH:
+		return numberKind(x, y)
+	case syntax.STAR:
+		if x&(kindString|kindList) != 0 && y == kindInt {
+			return x
+		}
+		if x == kindInt && y&(kindString|kindList) != 0 {
+			return y
+		}
+		return numberKind(x, y)
+	case syntax.SLASH: // true division always results in a float
+		if numberKind(x, y) != 0 {
+			return kindFloat
+		}
+	case syntax.PERCENT:
+		if x == kindString { // string formatting
+			return kindString
+		}
+		return numberKind(x, y)
+	case syntax.PIPE, syntax.AMP, syntax.CIRCUMFLEX, syntax.LTLT, syntax.GTGT:
+		if x == kindInt && y == kindInt {
+			return kindInt
+		}
+	}
+	return 0
+}
+
+// numberKind implements arithmetic, which results in an int for ints and is widened to float otherwise
+func numberKind(x, y kind) kind {
+	if x&kindNumber == 0 || y&kindNumber == 0 {
+		return 0
+	}
+	if x == kindInt && y == kindInt {
+		return kindInt
+	}
+	return kindFloat
+}
+
+var _ starlark.Comparable = &MagicType{}
+
+// CompareSameType fails for every comparison of two computed values, as its outcome is unknown. The linter replaces
+// the comparisons of linted code with a computed bool, so this only fails comparisons inside of loaded modules, just
+// like comparisons of computed values with other types, which starlark does not support.
+func (mt *MagicType) CompareSameType(op syntax.Token, y starlark.Value, depth int) (bool, error) {
+	return false, fmt.Errorf("%s %s %s not implemented", mt.Type(), op, y.Type())
 }
 
 var _ starlark.Sliceable = &MagicType{}
 
 func (mt *MagicType) Slice(start, end, step int) starlark.Value {
-	return &MagicType{
-		CouldBeString: true,
-		CouldBeInt:    true,
-		CouldBeFloat:  true,
+	if mt.CouldOnlyBe(MagicType{CouldBeString: true}) {
+		return &MagicType{CouldBeString: true}
 	}
+	if mt.CouldOnlyBe(MagicType{CouldBeList: true}) {
+		return ListOf(mt.Elem)
+	}
+	return Any()
 }
 
 func (mt *MagicType) Index(i int) starlark.Value {
-	return &MagicType{
-		CouldBeString: true,
-		CouldBeInt:    true,
-		CouldBeFloat:  true,
-	}
+	return mt.elem()
 }
 
 func (mt *MagicType) AsGoValue() interface{} {
@@ -0,0 +1,61 @@
+package magic
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/template/core"
+	"go.starlark.net/starlark"
+)
+
+// Struct behaves like the struct ytt creates for data values, but accessing an unknown attribute
+// results in a MagicType instead of an error
+type Struct struct {
+	data *orderedmap.Map
+	// Path is the expression the struct is accessible by, e.g. data.values.labels
+	Path string
+	// OnAttr is called on every attribute access, if set
+	OnAttr func(s *Struct, name string, found bool)
+}
+
+// NewStruct creates a Struct, the values of data must be starlark values
+func NewStruct(data *orderedmap.Map) *Struct {
+	return &Struct{data: data}
+}
+
+var _ starlark.HasAttrs = &Struct{}
+
+func (s *Struct) String() string        { return "struct(...)" }
+func (s *Struct) Type() string          { return "struct" }
+func (s *Struct) Freeze()               {}
+func (s *Struct) Truth() starlark.Bool  { return s.data.Len() > 0 }
+func (s *Struct) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable type: struct") }
+
+func (s *Struct) Attr(name string) (starlark.Value, error) {
+	val, found := s.data.Get(name)
+	if s.OnAttr != nil {
+		s.OnAttr(s, name, found)
+	}
+	if found {
+		return val.(starlark.Value), nil
+	}
+	return Any(), nil
+}
+
+func (s *Struct) AttrNames() []string {
+	var keys []string
+	s.data.Iterate(func(key, _ interface{}) {
+		keys = append(keys, key.(string))
+	})
+	return keys
+}
+
+func (s *Struct) AsGoValue() interface{} {
+	result := orderedmap.NewMap()
+	s.data.Iterate(func(key, val interface{}) {
+		result.Set(key, core.NewStarlarkValue(val.(starlark.Value)).AsGoValue())
+	})
+	return result
+}
+
+var _ core.StarlarkValueToGoValueConversion = &Struct{}
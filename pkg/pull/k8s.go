@@ -1,6 +1,7 @@
 package pull
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/SAP/ytt-lint/pkg/importer"
@@ -37,13 +38,13 @@ func Pull(kubeconfigPath, contextName string) error {
 		return fmt.Errorf("apiextensionsclientset.NewForConfig(): %w", err)
 	}
 
-	crds, err := crdClientset.ApiextensionsV1().CustomResourceDefinitions().List(metav1.ListOptions{})
+	crds, err := crdClientset.ApiextensionsV1().CustomResourceDefinitions().List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		if !k8serrors.IsNotFound(err) {
 			return fmt.Errorf("crdClientset.ApiextensionsV1().CustomResourceDefinitions().List(): %w", err)
 		}
 		fmt.Println("Error for ApiextensionsV1. Falling back to ApiextensionsV1beta1.")
-		crds, err := crdClientset.ApiextensionsV1beta1().CustomResourceDefinitions().List(metav1.ListOptions{})
+		crds, err := crdClientset.ApiextensionsV1beta1().CustomResourceDefinitions().List(context.Background(), metav1.ListOptions{})
 		if err != nil {
 			return fmt.Errorf("crdClientset.ApiextensionsV1().CustomResourceDefinitions().List(): %w", err)
 		}
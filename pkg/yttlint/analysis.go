@@ -0,0 +1,257 @@
+package yttlint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/template"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// analysis finds code, which does not fail during evaluation but most likely is not what the author intended. The
+// code of templates is analysed after compilation, so the positions are mapped back to the lines of the template.
+type analysis struct {
+	compiledTemplate *template.CompiledTemplate
+	// library is true for files other files load, so their public functions are used elsewhere
+	library bool
+
+	// uses counts the uses of a name by the identifier first binding it, as names used by nested functions are
+	// bound again
+	uses     map[*syntax.Ident]int
+	bindings map[*syntax.Ident]bool
+	errors   []LinterError
+}
+
+// analyse runs the static analysis on a template or starlark module. Files, which cannot be compiled, are not
+// analysed, as the compilation errors are reported anyway.
+func analyse(data, filename string) []LinterError {
+	var compiledTemplate *template.CompiledTemplate
+	if isStarlarkFile(filename) {
+		compiledTemplate = compileStarlark(data, filename)
+	} else {
+		compiledTemplate, _, _ = compile(data, filename)
+		if compiledTemplate == nil {
+			return nil
+		}
+	}
+
+	f, err := parse(filename, compiledTemplate.CodeAsString())
+	if err != nil {
+		return nil
+	}
+	// undefined names are reported by the evaluation, the bindings of all other names are known nevertheless
+	_ = resolve.File(f, isPredeclared, starlark.Universe.Has)
+
+	a := &analysis{
+		compiledTemplate: compiledTemplate,
+		library:          isStarlarkFile(filename) || isLibraryFile(filename),
+		uses:             map[*syntax.Ident]int{},
+		bindings:         map[*syntax.Ident]bool{},
+	}
+	a.collectBindings(f.Stmts)
+	syntax.Walk(f, func(node syntax.Node) bool {
+		if id, ok := node.(*syntax.Ident); ok && !a.bindings[id] {
+			if binding, ok := id.Binding.(*resolve.Binding); ok && binding.First != nil {
+				a.uses[binding.First]++
+			}
+		}
+		return true
+	})
+
+	a.stmts(f.Stmts, false)
+	return a.errors
+}
+
+// isPredeclared reports the names ytt and the linter predeclare for templates, e.g. the instructions of templates
+func isPredeclared(name string) bool {
+	return strings.HasPrefix(name, "__ytt_")
+}
+
+// parse parses starlark code with the dialect of ytt, which panics on syntax errors
+func parse(filename, code string) (f *syntax.File, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return syntax.Parse(filename, code, syntax.BlockScanner)
+}
+
+// collectBindings remembers all identifiers, which bind a name instead of using it
+func (a *analysis) collectBindings(stmts []syntax.Stmt) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *syntax.LoadStmt:
+			for _, id := range stmt.To {
+				a.bindings[id] = true
+			}
+		case *syntax.DefStmt:
+			a.bindings[stmt.Name] = true
+			for _, param := range stmt.Params {
+				if id := paramIdent(param); id != nil {
+					a.bindings[id] = true
+				}
+			}
+			a.collectBindings(stmt.Body)
+		case *syntax.AssignStmt:
+			if stmt.Op == syntax.EQ {
+				for _, id := range assignedIdents(stmt.LHS) {
+					a.bindings[id] = true
+				}
+			}
+		case *syntax.ForStmt:
+			for _, id := range assignedIdents(stmt.Vars) {
+				a.bindings[id] = true
+			}
+			a.collectBindings(stmt.Body)
+		case *syntax.WhileStmt:
+			a.collectBindings(stmt.Body)
+		case *syntax.IfStmt:
+			a.collectBindings(stmt.True)
+			a.collectBindings(stmt.False)
+		}
+	}
+}
+
+func (a *analysis) stmts(stmts []syntax.Stmt, inFunction bool) {
+	for i, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *syntax.LoadStmt:
+			for _, id := range stmt.To {
+				a.checkShadowing(id)
+				if a.isUnused(id) && !isIgnored(id) {
+					a.report(ErrorCodeUnusedLoad, id.NamePos, "%s is loaded but never used", id.Name)
+				}
+			}
+		case *syntax.DefStmt:
+			a.checkShadowing(stmt.Name)
+			if a.isUnused(stmt.Name) && (inFunction || !a.library || strings.HasPrefix(stmt.Name.Name, "_")) {
+				a.report(ErrorCodeUnusedFunction, stmt.Name.NamePos, "function %s is defined but never called", stmt.Name.Name)
+			}
+			for _, param := range stmt.Params {
+				if id := paramIdent(param); id != nil {
+					a.checkShadowing(id)
+				}
+			}
+			a.stmts(stmt.Body, true)
+		case *syntax.AssignStmt:
+			if stmt.Op == syntax.EQ {
+				for _, id := range assignedIdents(stmt.LHS) {
+					a.checkShadowing(id)
+					if inFunction && a.isUnused(id) && !isIgnored(id) {
+						a.report(ErrorCodeUnusedVariable, id.NamePos, "%s is assigned but never used", id.Name)
+					}
+				}
+			}
+		case *syntax.ForStmt:
+			for _, id := range assignedIdents(stmt.Vars) {
+				a.checkShadowing(id)
+			}
+			a.stmts(stmt.Body, inFunction)
+		case *syntax.WhileStmt:
+			a.stmts(stmt.Body, inFunction)
+		case *syntax.IfStmt:
+			a.stmts(stmt.True, inFunction)
+			a.stmts(stmt.False, inFunction)
+		}
+
+		if terminates(stmt) && i+1 < len(stmts) {
+			start, _ := stmts[i+1].Span()
+			a.report(ErrorCodeUnreachableCode, start, "unreachable code")
+			return
+		}
+	}
+}
+
+func (a *analysis) checkShadowing(id *syntax.Ident) {
+	if starlark.Universe.Has(id.Name) {
+		a.report(ErrorCodeShadowedBuiltin, id.NamePos, "%s shadows the builtin %s", id.Name, id.Name)
+	}
+}
+
+// isUnused reports whether the name bound by id is never used
+func (a *analysis) isUnused(id *syntax.Ident) bool {
+	binding, ok := id.Binding.(*resolve.Binding)
+	if !ok || binding.First != id {
+		return false
+	}
+	return a.uses[id] == 0
+}
+
+// isIgnored reports whether a loaded or assigned name is unused on purpose, e.g. _ in _, b = pair()
+func isIgnored(id *syntax.Ident) bool {
+	return strings.HasPrefix(id.Name, "_")
+}
+
+func (a *analysis) report(code ErrorCode, pos syntax.Position, format string, args ...interface{}) {
+	line := a.compiledTemplate.CodeAtLine(filepos.NewPosition(int(pos.Line)))
+	if line == nil || line.SourceLine == nil {
+		// generated code
+		return
+	}
+	a.errors = append(a.errors, LinterError{
+		Msg:  fmt.Sprintf(format, args...),
+		Pos:  line.SourceLine.Position.AsCompactString(),
+		Code: code,
+	})
+}
+
+// terminates reports whether the statements following stmt are never executed
+func terminates(stmt syntax.Stmt) bool {
+	switch stmt := stmt.(type) {
+	case *syntax.ReturnStmt:
+		return true
+	case *syntax.ExprStmt:
+		call, ok := stmt.X.(*syntax.CallExpr)
+		if !ok {
+			return false
+		}
+		id, ok := call.Fn.(*syntax.Ident)
+		if !ok {
+			return false
+		}
+		binding, ok := id.Binding.(*resolve.Binding)
+		return ok && id.Name == "fail" && binding.Scope == resolve.Universal
+	}
+	return false
+}
+
+func paramIdent(param syntax.Expr) *syntax.Ident {
+	switch param := param.(type) {
+	case *syntax.Ident:
+		return param
+	case *syntax.BinaryExpr: // name=default
+		id, _ := param.X.(*syntax.Ident)
+		return id
+	case *syntax.UnaryExpr: // *args or **kwargs
+		id, _ := param.X.(*syntax.Ident)
+		return id
+	}
+	return nil
+}
+
+// assignedIdents returns the names an assignment binds, e.g. a and b for a, [b, c[0]] = ...
+func assignedIdents(lhs syntax.Expr) []*syntax.Ident {
+	switch lhs := lhs.(type) {
+	case *syntax.Ident:
+		return []*syntax.Ident{lhs}
+	case *syntax.ParenExpr:
+		return assignedIdents(lhs.X)
+	case *syntax.TupleExpr:
+		return assignedIdentsOf(lhs.List)
+	case *syntax.ListExpr:
+		return assignedIdentsOf(lhs.List)
+	}
+	return nil
+}
+
+func assignedIdentsOf(list []syntax.Expr) []*syntax.Ident {
+	result := []*syntax.Ident{}
+	for _, item := range list {
+		result = append(result, assignedIdents(item)...)
+	}
+	return result
+}
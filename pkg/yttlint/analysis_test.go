@@ -0,0 +1,58 @@
+package yttlint
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestAnalysis(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	data, err := ioutil.ReadFile("../../examples/analysis/analysis.yaml")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	errors := analyse(string(data), "test")
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg:  "base64 is loaded but never used",
+		Pos:  "test:2",
+		Code: ErrorCodeUnusedLoad,
+	}, {
+		Msg:  "version is assigned but never used",
+		Pos:  "test:5",
+		Code: ErrorCodeUnusedVariable,
+	}, {
+		Msg:  "function _unused is defined but never called",
+		Pos:  "test:9",
+		Code: ErrorCodeUnusedFunction,
+	}, {
+		Msg:  "str shadows the builtin str",
+		Pos:  "test:13",
+		Code: ErrorCodeShadowedBuiltin,
+	}, {
+		Msg:  "unreachable code",
+		Pos:  "test:15",
+		Code: ErrorCodeUnreachableCode,
+	}}))
+
+	// public functions of modules are called by the files loading them
+	errors = analyse("def f(x):\n  return x\nend\ndef _g():\n  return 1\nend\n", "test.star")
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg:  "function _g is defined but never called",
+		Pos:  "test.star:4",
+		Code: ErrorCodeUnusedFunction,
+	}}))
+
+	// syntax errors are reported by the evaluation
+	errors = analyse("def f(x):\n  return x +\nend\n", "test.star")
+	g.Expect(errors).To(BeEmpty())
+
+	linter := &Linter{Disabled: []ErrorCode{ErrorCodeUnusedLoad}}
+	errors = linter.Lint("#@ load(\"@ytt:base64\", \"base64\")\n#@ load(\"@ytt:json\", str=\"json\")\na: 1\n", "test", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg:  "str shadows the builtin str",
+		Pos:  "test:2",
+		Code: ErrorCodeShadowedBuiltin,
+	}}))
+}
@@ -0,0 +1,455 @@
+package yttlint
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/structmeta"
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"go.starlark.net/starlark"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+// branchFuncName is the builtin every if/elif condition is wrapped in, so that the outcome of conditionals can be
+// forced during evaluation
+const branchFuncName = "__ytt_lint_branch"
+
+// iterateFuncName is the builtin the iterable of every for loop is wrapped in, so that loops over computed values can
+// be forced to not iterate at all
+const iterateFuncName = "__ytt_lint_iterate"
+
+// branchScenarioKey is the thread local holding the branchScenario which is currently evaluated
+const branchScenarioKey = "ytt-lint.branch_scenario"
+
+// forceBranch implements __ytt_lint_branch(conditional, branch, condition). Only computed conditions are forced by
+// the scenario being evaluated, conditions with a known outcome (e.g. depending on a given data value) are evaluated
+// as is.
+func forceBranch(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var id, branch int
+	var condition starlark.Value
+	err := starlark.UnpackPositionalArgs(f.Name(), args, kwargs, 3, &id, &branch, &condition)
+	if err != nil {
+		return starlark.None, err
+	}
+
+	taken := condition.Truth()
+	if _, ok := condition.(*magic.MagicType); ok {
+		scenario, _ := thread.Local(branchScenarioKey).(*branchScenario)
+		if scenario != nil {
+			if forced, ok := scenario.forced[id]; ok {
+				taken = starlark.Bool(forced == branch)
+			}
+			if taken {
+				scenario.computed[id] = branch
+			}
+		}
+	}
+	return taken, nil
+}
+
+// forceIteration implements __ytt_lint_iterate(loop, iterable). Computed values iterate exactly once, unless the
+// scenario being evaluated forces the loop to not iterate at all.
+func forceIteration(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var id int
+	var iterable starlark.Value
+	err := starlark.UnpackPositionalArgs(f.Name(), args, kwargs, 2, &id, &iterable)
+	if err != nil {
+		return starlark.None, err
+	}
+
+	if _, ok := iterable.(*magic.MagicType); ok {
+		scenario, _ := thread.Local(branchScenarioKey).(*branchScenario)
+		if scenario != nil && scenario.forced[id] == 1 {
+			return starlark.NewList([]starlark.Value{}), nil
+		}
+	}
+	return iterable, nil
+}
+
+type conditionalBranch struct {
+	keyword  string // if, elif or else
+	position *filepos.Position
+	lastLine int
+}
+
+// conditional is an if/elif/else chain or a for loop of a template. A loop has a single branch, its body, which is
+// either rendered or not.
+type conditional struct {
+	id       int
+	branches []*conditionalBranch
+	hasElse  bool
+	loop     bool
+
+	parent       *conditional
+	parentBranch int
+}
+
+// outcomes returns the number of distinct outcomes of the conditional, not rendering any branch counts as one if
+// there is no else
+func (c *conditional) outcomes() int {
+	if c.hasElse {
+		return len(c.branches)
+	}
+	return len(c.branches) + 1
+}
+
+func (c *conditional) contains(line int) (int, bool) {
+	for i, branch := range c.branches {
+		if branch.position.Line() <= line && line <= branch.lastLine {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// branchScenario forces the outcome of a conditional (and of all conditionals enclosing it). The scenario without a
+// conditional forces nothing, it evaluates the template as is.
+type branchScenario struct {
+	conditional *conditional
+	branch      int
+	forced      map[int]int
+	// computed records the branches, which have been taken because of a computed condition during the evaluation
+	computed map[int]int
+}
+
+func newUnforcedScenario() *branchScenario {
+	return &branchScenario{forced: map[int]int{}, computed: map[int]int{}}
+}
+
+func newBranchScenario(c *conditional, branch int) *branchScenario {
+	scenario := &branchScenario{
+		conditional: c,
+		branch:      branch,
+		forced:      map[int]int{c.id: branch},
+		computed:    map[int]int{},
+	}
+	for child, parent := c, c.parent; parent != nil; child, parent = parent, parent.parent {
+		scenario.forced[parent.id] = child.parentBranch
+	}
+	return scenario
+}
+
+// branchScenarios returns a scenario for every outcome of every conditional
+func branchScenarios(conditionals []*conditional) []*branchScenario {
+	scenarios := []*branchScenario{}
+	for _, c := range conditionals {
+		for branch := 0; branch < c.outcomes(); branch++ {
+			scenarios = append(scenarios, newBranchScenario(c, branch))
+		}
+	}
+	return scenarios
+}
+
+// inForcedBranch reports whether err is located inside the branch forced by the scenario
+func (s *branchScenario) inForcedBranch(err LinterError) bool {
+	if s.conditional == nil || s.branch >= len(s.conditional.branches) {
+		return false
+	}
+	return inBranch(err, s.conditional.branches[s.branch])
+}
+
+// inComputedBranch reports whether err is located inside a branch taken because of a computed condition
+func (s *branchScenario) inComputedBranch(err LinterError, conditionals []*conditional) bool {
+	for id, branch := range s.computed {
+		if inBranch(err, conditionals[id].branches[branch]) {
+			return true
+		}
+	}
+	return false
+}
+
+func inBranch(err LinterError, branch *conditionalBranch) bool {
+	file, line, ok := splitPos(err.Pos)
+	return ok && file == positionFile(branch.position) && branch.position.Line() <= line && line <= branch.lastLine
+}
+
+// withoutExpectedFailures removes calls of fail() inside the forced branch and inside branches taken because of a
+// computed condition (e.g. assert.fail in a guard like `if not data.values.name:`), as taking these branches is
+// expected to reach them. Evaluation errors are reported once per frame of the call stack, so all errors with the
+// message of such a failure are removed.
+func (s *branchScenario) withoutExpectedFailures(errors []LinterError, conditionals []*conditional) []LinterError {
+	expected := map[string]bool{}
+	for _, err := range errors {
+		if isFailure(err.Msg) && (s.inForcedBranch(err) || s.inComputedBranch(err, conditionals)) {
+			expected[err.Msg] = true
+		}
+	}
+
+	result := []LinterError{}
+	for _, err := range errors {
+		if !expected[err.Msg] {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
+// attribute explains under which circumstances err occurs. Errors reported inside the forced branch speak for
+// themselves and are left untouched.
+func (s *branchScenario) attribute(err LinterError) LinterError {
+	if s.branch < len(s.conditional.branches) {
+		if s.inForcedBranch(err) {
+			return err
+		}
+		branch := s.conditional.branches[s.branch]
+		err.Msg = fmt.Sprintf("%s (when the %s branch at %s is taken)", err.Msg, branch.keyword, branch.position.AsCompactString())
+		return err
+	}
+
+	if s.conditional.loop {
+		err.Msg = fmt.Sprintf("%s (when the loop at %s has no iterations)", err.Msg, s.conditional.branches[0].position.AsCompactString())
+		return err
+	}
+	err.Msg = fmt.Sprintf("%s (when the condition at %s is false)", err.Msg, s.conditional.branches[0].position.AsCompactString())
+	return err
+}
+
+var arrayIndexRegexp = regexp.MustCompile(`\[\d+\]`)
+
+// failureRegexp matches errors raised by fail() or assert.fail(), which are prefixed with the names of the builtins
+// on the call stack
+var failureRegexp = regexp.MustCompile(`^([\w.]+: )*fail: `)
+
+func isFailure(msg string) bool {
+	return failureRegexp.MatchString(msg)
+}
+
+// branchErrorKey identifies errors across scenarios. Array indices are ignored, since they shift if branches render a
+// different number of items.
+func branchErrorKey(err LinterError) string {
+	return fmt.Sprintf("%s %s %s", err.Pos, err.Code, arrayIndexRegexp.ReplaceAllString(err.Msg, "[]"))
+}
+
+func splitPos(pos string) (string, int, bool) {
+	idx := strings.LastIndex(pos, ":")
+	if idx < 0 {
+		return "", 0, false
+	}
+	line, err := strconv.Atoi(pos[idx+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return pos[:idx], line, true
+}
+
+func positionFile(position *filepos.Position) string {
+	file, _, _ := splitPos(position.AsCompactString())
+	return file
+}
+
+type metaWithNode struct {
+	meta *yamlmeta.Meta
+	node yamlmeta.Node
+}
+
+// injectBranchHandling wraps the conditions of all conditionals into __ytt_lint_branch and the iterables of all loops
+// into __ytt_lint_iterate, so that every branch can be evaluated. It returns the conditionals and loops found, no
+// matter whether they guard map items, array items or documents.
+func injectBranchHandling(docSet *yamlmeta.DocumentSet) []*conditional {
+	metas := collectMetas(docSet, []metaWithNode{})
+	sort.SliceStable(metas, func(i, j int) bool {
+		return metas[i].meta.Position.Line() < metas[j].meta.Position.Line()
+	})
+
+	type block struct {
+		conditional *conditional
+	}
+	stack := []*block{}
+	var lastNodeConditional *conditional
+	all := []*conditional{}
+
+	closeBranch := func(c *conditional, line int) {
+		if c != nil && len(c.branches) > 0 && c.branches[len(c.branches)-1].lastLine == math.MaxInt32 {
+			c.branches[len(c.branches)-1].lastLine = line - 1
+		}
+	}
+
+	for _, item := range metas {
+		meta := item.meta
+		if item.node.GetPosition().IsKnown() && meta.Position.Line() == item.node.GetPosition().Line() {
+			continue // value of the node, not a statement
+		}
+
+		structMeta, err := structmeta.NewMetaFromString(meta.Data, structmeta.MetaOpts{IgnoreUnknown: true})
+		if err != nil {
+			continue // reported by ytt itself
+		}
+
+		for _, ann := range structMeta.Annotations {
+			if ann.Name != "" && ann.Name != template.AnnotationCode {
+				continue
+			}
+
+			code := strings.TrimSpace(ann.Content)
+			keyword := strings.FieldsFunc(code, func(r rune) bool { return unicode.IsSpace(r) || r == ':' })
+			if len(keyword) == 0 {
+				continue
+			}
+			opensBlock := strings.HasSuffix(code, ":")
+
+			var c *conditional
+			branch := &conditionalBranch{position: meta.Position, lastLine: math.MaxInt32}
+
+			switch keyword[0] {
+			case "if", "if/end":
+				if !opensBlock {
+					continue
+				}
+				c = &conditional{id: len(all)}
+				all = append(all, c)
+				if keyword[0] == "if" {
+					stack = append(stack, &block{conditional: c})
+				} else {
+					lastNodeConditional = c
+				}
+
+			case "elif", "else":
+				if len(stack) == 0 || stack[len(stack)-1].conditional == nil || stack[len(stack)-1].conditional.loop {
+					continue
+				}
+				c = stack[len(stack)-1].conditional
+				closeBranch(c, meta.Position.Line())
+
+			case "elif/end", "else/end":
+				c = lastNodeConditional
+				if c == nil {
+					continue
+				}
+
+			case "for", "for/end":
+				inIdx := strings.Index(code, " in ")
+				if !opensBlock || inIdx < 0 {
+					continue
+				}
+				c = &conditional{id: len(all), loop: true}
+				all = append(all, c)
+				if keyword[0] == "for" {
+					stack = append(stack, &block{conditional: c})
+				}
+
+				iterable := strings.TrimSpace(code[inIdx+4 : len(code)-1])
+				rewriteCode(meta, ann, fmt.Sprintf("%s in %s(%d, %s):", code[:inIdx], iterateFuncName, c.id, iterable))
+
+				branch.keyword = "for"
+				if keyword[0] == "for/end" {
+					branch.lastLine = lastLine(item.node)
+				}
+				c.branches = append(c.branches, branch)
+				continue
+
+			case "def":
+				if opensBlock {
+					stack = append(stack, &block{})
+				}
+				continue
+
+			case "end":
+				if len(stack) > 0 {
+					closeBranch(stack[len(stack)-1].conditional, meta.Position.Line())
+					stack = stack[:len(stack)-1]
+				}
+				continue
+
+			default:
+				continue
+			}
+
+			branch.keyword = strings.TrimSuffix(keyword[0], "/end")
+			if strings.HasSuffix(keyword[0], "/end") {
+				branch.lastLine = lastLine(item.node)
+			}
+			if branch.keyword == "else" {
+				c.hasElse = true
+			} else {
+				condition := strings.TrimSpace(code[len(keyword[0]) : len(code)-1])
+				rewriteCode(meta, ann, fmt.Sprintf("%s %s(%d, %d, %s):", keyword[0], branchFuncName, c.id, len(c.branches), condition))
+			}
+			c.branches = append(c.branches, branch)
+		}
+	}
+
+	// a conditional belongs to the innermost branch surrounding it
+	for _, c := range all {
+		line := c.branches[0].position.Line()
+		for _, candidate := range all {
+			if candidate == c {
+				continue
+			}
+			branch, ok := candidate.contains(line)
+			if !ok {
+				continue
+			}
+			if c.parent == nil || c.parent.branches[0].position.Line() < candidate.branches[0].position.Line() {
+				c.parent = candidate
+				c.parentBranch = branch
+			}
+		}
+	}
+
+	return all
+}
+
+// rewriteCode replaces the code of a code annotation, keeping its indentation
+func rewriteCode(meta *yamlmeta.Meta, ann *structmeta.Annotation, code string) {
+	indentation := ann.Content[:len(ann.Content)-len(strings.TrimLeftFunc(ann.Content, unicode.IsSpace))]
+	meta.Data = meta.Data[:len(meta.Data)-len(ann.Content)] + indentation + code
+}
+
+func collectMetas(val interface{}, metas []metaWithNode) []metaWithNode {
+	switch typedVal := val.(type) {
+	case *yamlmeta.DocumentSet:
+		for _, item := range typedVal.Items {
+			metas = collectMetas(item, metas)
+		}
+	case *yamlmeta.Document:
+		metas = appendMetas(metas, typedVal)
+		metas = collectMetas(typedVal.Value, metas)
+	case *yamlmeta.Map:
+		for _, item := range typedVal.Items {
+			metas = appendMetas(metas, item)
+			metas = collectMetas(item.Value, metas)
+		}
+	case *yamlmeta.Array:
+		for _, item := range typedVal.Items {
+			metas = appendMetas(metas, item)
+			metas = collectMetas(item.Value, metas)
+		}
+	}
+	return metas
+}
+
+func appendMetas(metas []metaWithNode, node yamlmeta.Node) []metaWithNode {
+	for _, meta := range node.GetMetas() {
+		if !meta.Position.IsKnown() {
+			continue
+		}
+		metas = append(metas, metaWithNode{meta: meta, node: node})
+	}
+	return metas
+}
+
+// lastLine returns the last source line of node and its children
+func lastLine(node yamlmeta.Node) int {
+	last := 0
+	if node.GetPosition().IsKnown() {
+		last = node.GetPosition().Line()
+	}
+	for _, value := range node.GetValues() {
+		child, ok := value.(yamlmeta.Node)
+		if !ok {
+			continue
+		}
+		if line := lastLine(child); line > last {
+			last = line
+		}
+	}
+	return last
+}
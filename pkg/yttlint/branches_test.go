@@ -0,0 +1,39 @@
+package yttlint
+
+import (
+	"testing"
+)
+
+func TestLoops(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "loops",
+		schema: `{"type": "object", "properties": {
+			"containers": {"type": "array", "minItems": 1, "items": {"type": "object", "properties": {"name": {"type": "string"}}}},
+			"replicas": {"type": "array", "items": {"type": "integer"}},
+			"ports": {"type": "array", "minItems": 1, "items": {"type": "integer"}}
+		}}`,
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"containers:\n" +
+			"#@ for c in data.values.containers:\n" +
+			"- name: #@ c.name\n" +
+			"#@ end\n" +
+			"replicas:\n" +
+			"#@ for/end i in range(data.values.count):\n" +
+			"- #@ \"replica-{}\".format(i)\n" +
+			"ports:\n" +
+			"#@ for port in [80, 443]:\n" +
+			"- #@ port\n" +
+			"#@ end\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".replicas[0] expected integer got: string",
+			Pos: "test:8",
+		}, {
+			Msg: ".containers must have at least 1 items got: 0 (when the loop at test:3 has no iterations)",
+			Pos: "test:2",
+		}},
+		pedanticErrors: []LinterError{{
+			Msg: ".containers[0].name expected string got a computed value. Tip: use str(...) to convert to string",
+			Pos: "test:4",
+		}},
+	}})
+}
@@ -0,0 +1,227 @@
+package yttlint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/k14s/ytt/pkg/template"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+// rangeFuncName is the builtin calls of range are replaced with, so that ranges with computed bounds can be linted.
+// The range builtin of starlark stays untouched, as ytt evaluates other code (e.g. data values) with it as well.
+const rangeFuncName = "__ytt_lint_range"
+
+// compareFuncName is the builtin comparisons are replaced with, since starlark does not allow computed values to
+// implement comparisons with other types
+const compareFuncName = "__ytt_lint_compare"
+
+var starlarkRange = starlark.Universe["range"]
+
+// comparisonOps are the operators replaced by __ytt_lint_compare
+var comparisonOps = map[string]syntax.Token{
+	syntax.EQL.String(): syntax.EQL,
+	syntax.NEQ.String(): syntax.NEQ,
+	syntax.LT.String():  syntax.LT,
+	syntax.LE.String():  syntax.LE,
+	syntax.GT.String():  syntax.GT,
+	syntax.GE.String():  syntax.GE,
+}
+
+// lintBuiltinsModule provides the builtins of the linter. Only the linted code loads it, so neither the code of other
+// modules nor the builtins of starlark are affected by them.
+const lintBuiltinsModule = "@ytt-lint:builtins"
+
+// lintBuiltins returns the builtins of the linter by the names the linted code refers to them with
+func lintBuiltins() []*starlark.Builtin {
+	return []*starlark.Builtin{
+		starlark.NewBuiltin(branchFuncName, forceBranch),
+		starlark.NewBuiltin(compareFuncName, computedComparison),
+		starlark.NewBuiltin(iterateFuncName, forceIteration),
+		starlark.NewBuiltin(rangeFuncName, computedRange),
+	}
+}
+
+// exportedName is the name a builtin of the linter is exported with by lintBuiltinsModule, as names starting with _
+// cannot be loaded
+func exportedName(name string) string {
+	return strings.TrimLeft(name, "_")
+}
+
+// lintBuiltinsMembers returns the members of lintBuiltinsModule
+func lintBuiltinsMembers() starlark.StringDict {
+	members := starlark.StringDict{}
+	for _, builtin := range lintBuiltins() {
+		members[exportedName(builtin.Name())] = builtin
+	}
+	return members
+}
+
+// loadLintBuiltins returns the load statement binding the builtins of the linter by their names
+func loadLintBuiltins() string {
+	args := []string{fmt.Sprintf("%q", lintBuiltinsModule)}
+	for _, builtin := range lintBuiltins() {
+		args = append(args, fmt.Sprintf("%s=%q", builtin.Name(), exportedName(builtin.Name())))
+	}
+	return fmt.Sprintf("load(%s)", strings.Join(args, ", "))
+}
+
+// computedRange implements __ytt_lint_range(...). It returns a computed value for ranges with computed bounds. Loops
+// over them are handled like loops over any other computed value.
+func computedRange(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	for _, arg := range args {
+		if _, ok := arg.(*magic.MagicType); ok {
+			return magic.ListOf(&magic.MagicType{CouldBeInt: true}), nil
+		}
+	}
+
+	return starlark.Call(thread, starlarkRange, args, kwargs)
+}
+
+// computedComparison implements __ytt_lint_compare(op, x, y). Comparisons involving a computed value could have any
+// outcome, so they result in a computed bool. This way conditionals comparing computed values are explored like any
+// other computed condition. All other comparisons are evaluated as is.
+func computedComparison(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var op string
+	var x, y starlark.Value
+	err := starlark.UnpackPositionalArgs(f.Name(), args, kwargs, 3, &op, &x, &y)
+	if err != nil {
+		return starlark.None, err
+	}
+
+	_, xComputed := x.(*magic.MagicType)
+	_, yComputed := y.(*magic.MagicType)
+	if xComputed || yComputed {
+		return &magic.MagicType{CouldBeBool: true}, nil
+	}
+
+	result, err := starlark.Compare(comparisonOps[op], x, y)
+	if err != nil {
+		return starlark.None, err
+	}
+	return starlark.Bool(result), nil
+}
+
+// codeEdit replaces length runes starting at col (1-based) of a line of compiled code with text
+type codeEdit struct {
+	line   int
+	col    int
+	length int
+	text   string
+}
+
+// injectLintBuiltins replaces uses of starlark builtins, which have to behave differently for computed values, with
+// the builtins of the linter, e.g. range with __ytt_lint_range, and comparisons like x > y with
+// __ytt_lint_compare(">", x, y). The builtins are loaded in the first line of code, which has to be a simple statement
+// (e.g. the instruction setting the type of a yaml template) or empty. The code of the compiled template is changed in
+// place and line by line, so positions of errors still point to the template.
+func injectLintBuiltins(compiledTemplate *template.CompiledTemplate) {
+	f, err := parse("", compiledTemplate.CodeAsString())
+	if err != nil {
+		return // syntax errors are reported by the evaluation
+	}
+	edits := []codeEdit{{line: 1, col: 1, text: loadLintBuiltins() + "; "}}
+	if strings.TrimSpace(compiledTemplate.Code()[0].Instruction.AsString()) == "" {
+		edits[0].text = loadLintBuiltins()
+	}
+	// undefined names are reported by the evaluation, the bindings of all other names are known nevertheless
+	_ = resolve.File(f, isPredeclared, starlark.Universe.Has)
+
+	syntax.Walk(f, func(node syntax.Node) bool {
+		if id, ok := node.(*syntax.Ident); ok && id.Name == "range" && isUniversal(id) {
+			edits = append(edits, codeEdit{
+				line:   int(id.NamePos.Line),
+				col:    int(id.NamePos.Col),
+				length: len(id.Name),
+				text:   rangeFuncName,
+			})
+		}
+		if binary, ok := node.(*syntax.BinaryExpr); ok {
+			if _, ok := comparisonOps[binary.Op.String()]; ok {
+				edits = append(edits, comparisonEdits(binary)...)
+			}
+		}
+		return true
+	})
+
+	applyCodeEdits(compiledTemplate, edits)
+}
+
+// comparisonEdits rewrites x op y to __ytt_lint_compare("op", x, y) without moving x or y to other lines
+func comparisonEdits(binary *syntax.BinaryExpr) []codeEdit {
+	op := binary.Op.String()
+	start, xEnd := binary.X.Span()
+	_, end := binary.Y.Span()
+
+	opEdit := codeEdit{line: int(binary.OpPos.Line), col: int(binary.OpPos.Col), length: len(op), text: ","}
+	if xEnd.Line == binary.OpPos.Line {
+		// also replace the whitespace in front of the operator
+		opEdit.col = int(xEnd.Col)
+		opEdit.length += int(binary.OpPos.Col - xEnd.Col)
+	}
+
+	return []codeEdit{{
+		line: int(start.Line),
+		col:  int(start.Col),
+		text: fmt.Sprintf("%s(%q, ", compareFuncName, op),
+	}, opEdit, {
+		line: int(end.Line),
+		col:  int(end.Col),
+		text: ")",
+	}}
+}
+
+// isUniversal reports whether an identifier refers to a builtin of starlark, i.e. it is not shadowed
+func isUniversal(id *syntax.Ident) bool {
+	binding, ok := id.Binding.(*resolve.Binding)
+	return ok && binding.Scope == resolve.Universal
+}
+
+// applyCodeEdits changes the lines of a compiled template. The lines are the same CodeAsString joins, so the
+// positions of the parsed code can be used.
+func applyCodeEdits(compiledTemplate *template.CompiledTemplate, edits []codeEdit) {
+	if len(edits) == 0 {
+		return
+	}
+	// apply the edits from the end of a line, so the columns of the remaining edits stay valid. At the same column
+	// replacements go first, so text inserted in front of them is not replaced.
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line < edits[j].line
+		}
+		if edits[i].col != edits[j].col {
+			return edits[i].col > edits[j].col
+		}
+		return edits[i].length > edits[j].length
+	})
+
+	instructions := &template.InstructionSet{}
+	code := compiledTemplate.Code()
+	cont := false
+	for i, line := range code {
+		src := line.Instruction.AsString()
+		if !cont {
+			src = strings.TrimLeftFunc(src, unicode.IsSpace)
+		}
+		cont = strings.HasSuffix(src, "\\")
+
+		runes := []rune(src)
+		changed := false
+		for len(edits) > 0 && edits[0].line == i+1 {
+			edit := edits[0]
+			edits = edits[1:]
+			start := edit.col - 1
+			runes = append(runes[:start], append([]rune(edit.text), runes[start+edit.length:]...)...)
+			changed = true
+		}
+		if changed {
+			code[i].Instruction = instructions.NewCode(string(runes))
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package yttlint
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+func TestInjectLintBuiltins(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	compiledTemplate := compileStarlark("def f(n):\n  return [i for i in range(n)]\nend\n"+
+		"def g(range):\n  return range(1)\nend\n"+
+		"x = \"ä\" + str(len(range(2)))\n"+
+		"def h(a, b):\n  return a >= b or (a ==\n  \"x\")\nend\n", "test.star")
+	g.Expect(compiledTemplate.CodeAsString()).To(Equal(loadLintBuiltins() + "\n" +
+		"def f(n):\n" +
+		"return [i for i in __ytt_lint_range(n)]\n" +
+		"end\n" +
+		"def g(range):\n" +
+		"return range(1)\n" +
+		"end\n" +
+		"x = \"ä\" + str(len(__ytt_lint_range(2)))\n" +
+		"def h(a, b):\n" +
+		"return __ytt_lint_compare(\">=\", a, b) or (__ytt_lint_compare(\"==\", a,\n" +
+		"\"x\"))\n" +
+		"end\n"))
+
+	// other code evaluated by ytt keeps the builtins of starlark and does not see the ones of the linter
+	g.Expect(starlark.Universe["range"]).To(BeIdenticalTo(starlarkRange))
+	for _, builtin := range lintBuiltins() {
+		g.Expect(starlark.Universe).NotTo(HaveKey(builtin.Name()))
+	}
+}
+
+func TestComputedComparisonsInModules(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// comparisons inside of loaded modules are not replaced, they fail like comparisons with other types, which are not
+	// reported
+	for _, op := range []syntax.Token{syntax.LT, syntax.GE, syntax.EQL} {
+		_, err := starlark.Compare(op, magic.Any(), &magic.MagicType{CouldBeInt: true})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(magicComparisonRegexp.MatchString(err.Error())).To(BeTrue())
+	}
+}
+
+func TestComputedAttrNames(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect((&magic.MagicType{CouldBeString: true}).AttrNames()).To(ContainElements("lower", "split", "startswith"))
+	g.Expect((&magic.MagicType{CouldBeDict: true}).AttrNames()).To(Equal([]string{"get", "items", "keys", "values"}))
+	g.Expect((&magic.MagicType{CouldBeInt: true, CouldBeStruct: true}).AttrNames()).To(BeEmpty())
+}
@@ -0,0 +1,83 @@
+package yttlint
+
+import (
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// validateCombinators evaluates allOf, anyOf, oneOf and not. If no branch of anyOf or oneOf matches, only the errors
+// of the branch which matches best (least errors) are reported.
+func (l *Linter) validateCombinators(defs v1.JSONSchemaDefinitions, subSchema, schema *v1.JSONSchemaProps, path string) []LinterError {
+	errors := make([]LinterError, 0)
+
+	for _, branch := range schema.AllOf {
+		errors = append(errors, l.isSubset(defs, subSchema, &branch, path)...)
+	}
+
+	if len(schema.AnyOf) > 0 {
+		matches, bestErrors := l.evaluateBranches(defs, subSchema, schema.AnyOf, path)
+		if matches == 0 {
+			errors = append(errors, bestErrors...)
+		}
+	}
+
+	if len(schema.OneOf) > 0 {
+		matches, bestErrors := l.evaluateBranches(defs, subSchema, schema.OneOf, path)
+		if matches == 0 {
+			errors = append(errors, bestErrors...)
+		} else if matches > 1 && !containsMagic(subSchema) {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s matches %d schemas, but exactly one is allowed (oneOf)", path, matches))
+		}
+	}
+
+	if schema.Not != nil && !containsMagic(subSchema) {
+		if len(l.isSubset(defs, subSchema, schema.Not, path)) == 0 {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s must not match the schema given by not", path))
+		}
+	}
+
+	return errors
+}
+
+// evaluateBranches returns how many branches match subSchema and the errors of the best matching branch
+func (l *Linter) evaluateBranches(defs v1.JSONSchemaDefinitions, subSchema *v1.JSONSchemaProps, branches []v1.JSONSchemaProps, path string) (int, []LinterError) {
+	matches := 0
+	var bestErrors []LinterError
+
+	for _, branch := range branches {
+		branchErrors := l.isSubset(defs, subSchema, &branch, path)
+		if len(branchErrors) == 0 {
+			matches++
+			continue
+		}
+		if bestErrors == nil || len(branchErrors) < len(bestErrors) {
+			bestErrors = branchErrors
+		}
+	}
+
+	return matches, bestErrors
+}
+
+// containsMagic reports whether any part of subSchema was computed during evaluation
+func containsMagic(subSchema *v1.JSONSchemaProps) bool {
+	if subSchema.Type == "magic" {
+		return true
+	}
+	for _, item := range subSchema.AnyOf {
+		if containsMagic(&item) {
+			return true
+		}
+	}
+	for _, prop := range subSchema.Properties {
+		if containsMagic(&prop) {
+			return true
+		}
+	}
+	if subSchema.Items != nil {
+		for _, item := range subSchema.Items.JSONSchemas {
+			if containsMagic(&item) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package yttlint
+
+import (
+	"testing"
+)
+
+func TestCombinators(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "combinators",
+		schema: `{"type": "object", "properties": {
+			"issuer": {
+				"type": "object",
+				"properties": {
+					"acme": {"type": "object", "properties": {"server": {"type": "string"}}, "required": ["server"]},
+					"ca": {"type": "object", "properties": {"secretName": {"type": "string"}}, "required": ["secretName"]},
+					"selfSigned": {"type": "object", "properties": {}}
+				},
+				"oneOf": [{"required": ["acme"]}, {"required": ["ca"]}, {"required": ["selfSigned"]}]
+			},
+			"port": {"anyOf": [{"type": "integer", "maximum": 65535}, {"type": "string", "pattern": "[a-z]+"}]},
+			"limits": {"allOf": [{"type": "integer", "minimum": 1}, {"type": "integer", "maximum": 10}]},
+			"mode": {"type": "string", "not": {"enum": ["legacy"]}}
+		}}`,
+		document: "issuer:\n" +
+			"  ca:\n" +
+			"    secretName: ca\n" +
+			"  selfSigned: {}\n" +
+			"port: 70000\n" +
+			"limits: 11\n" +
+			"mode: legacy\n" +
+			"---\n" +
+			"issuer:\n" +
+			"  ca:\n" +
+			"    secretNam: ca\n" +
+			"port: http\n" +
+			"limits: 5\n" +
+			"mode: modern\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".issuer matches 2 schemas, but exactly one is allowed (oneOf)",
+			Pos: "test:1",
+		}, {
+			Msg: ".port invalid value 70000. Must be less than or equal to 65535",
+			Pos: "test:5",
+		}, {
+			Msg: ".limits invalid value 11. Must be less than or equal to 10",
+			Pos: "test:6",
+		}, {
+			Msg: ".mode must not match the schema given by not",
+			Pos: "test:7",
+		}, {
+			Msg: ".issuer.ca missing required entry secretName",
+			Pos: "test:10",
+		}, {
+			Msg: ".issuer.ca.secretNam additional properties are not permitted. Did you mean: secretName?",
+			Pos: "test:11",
+		}},
+		pedanticErrors: []LinterError{},
+	}})
+}
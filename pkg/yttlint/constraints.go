@@ -0,0 +1,221 @@
+package yttlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// isMultipleOf divides the decimal representations of the numbers, as binary floats like 0.1 are not exact, so that
+// e.g. 0.3 is a multiple of 0.1
+func isMultipleOf(raw string, value, multipleOf float64) bool {
+	v, ok := new(big.Rat).SetString(raw)
+	if !ok {
+		v, ok = new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	m, mOk := new(big.Rat).SetString(strconv.FormatFloat(multipleOf, 'g', -1, 64))
+	if !ok || !mOk {
+		quotient := value / multipleOf
+		return quotient == math.Trunc(quotient)
+	}
+	return v.Quo(v, m).IsInt()
+}
+
+// isLiteral reports whether subSchema was converted from a plain scalar value (and not from a computed one)
+func isLiteral(subSchema *v1.JSONSchemaProps) bool {
+	switch subSchema.Type {
+	case "string", "integer", "number", "boolean":
+		return subSchema.Default != nil
+	}
+	return false
+}
+
+// literalValue decodes a value, which was converted from plain values only, e.g. a map of scalars. Numbers are
+// decoded as float64, so that 1 and 1.0 are equal.
+func literalValue(subSchema *v1.JSONSchemaProps) (interface{}, bool) {
+	switch subSchema.Type {
+	case "object":
+		result := map[string]interface{}{}
+		for key, prop := range subSchema.Properties {
+			value, ok := literalValue(&prop)
+			if !ok {
+				return nil, false
+			}
+			result[key] = value
+		}
+		return result, true
+	case "array":
+		if subSchema.Items == nil {
+			return nil, false
+		}
+		result := []interface{}{}
+		for _, item := range subSchema.Items.JSONSchemas {
+			value, ok := literalValue(&item)
+			if !ok {
+				return nil, false
+			}
+			result = append(result, value)
+		}
+		return result, true
+	case "null":
+		return nil, true
+	}
+	if !isLiteral(subSchema) {
+		return nil, false
+	}
+	var result interface{}
+	err := json.Unmarshal(subSchema.Default.Raw, &result)
+	return result, err == nil
+}
+
+func (l *Linter) validateEnum(subSchema, schema *v1.JSONSchemaProps, path string) []LinterError {
+	errors := make([]LinterError, 0)
+
+	if len(schema.Enum) == 0 {
+		return errors
+	}
+
+	allowed := make([]string, 0, len(schema.Enum))
+	for _, item := range schema.Enum {
+		allowed = append(allowed, string(item.Raw))
+	}
+
+	if subSchema.Type == "magic" {
+		if l.Pedantic {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected one of %s got a computed value", path, strings.Join(allowed, ", ")))
+		}
+		return errors
+	}
+
+	if !isLiteral(subSchema) {
+		return errors
+	}
+
+	var value interface{}
+	err := json.Unmarshal(subSchema.Default.Raw, &value)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, item := range schema.Enum {
+		var candidate interface{}
+		err := json.Unmarshal(item.Raw, &candidate)
+		if err != nil {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s could not validate value, schema contains invalid enum value: %s", path, string(item.Raw)))
+			return errors
+		}
+		if reflect.DeepEqual(value, candidate) {
+			return errors
+		}
+	}
+
+	message := fmt.Sprintf("%s invalid value %s. Expected one of: %s", path, string(subSchema.Default.Raw), strings.Join(allowed, ", "))
+
+	if str, ok := value.(string); ok {
+		candidates := []string{}
+		for _, item := range schema.Enum {
+			var candidate string
+			if json.Unmarshal(item.Raw, &candidate) == nil {
+				candidates = append(candidates, candidate)
+			}
+		}
+		alternatives := suggestAlternatives(str, candidates)
+		if len(alternatives) != 0 {
+			message = fmt.Sprintf("%s. Did you mean: %s?", message, strings.Join(alternatives, ", "))
+		}
+	}
+
+	errors = append(errors, appendLocationIfKnownf(subSchema, "%s", message))
+	return errors
+}
+
+func (l *Linter) validateBounds(subSchema, schema *v1.JSONSchemaProps, path string) []LinterError {
+	errors := make([]LinterError, 0)
+
+	switch subSchema.Type {
+	case "integer", "number":
+		if subSchema.Default == nil {
+			break
+		}
+		var value float64
+		err := json.Unmarshal(subSchema.Default.Raw, &value)
+		if err != nil {
+			panic(err)
+		}
+		raw := string(subSchema.Default.Raw)
+
+		if schema.Minimum != nil {
+			if schema.ExclusiveMinimum && value <= *schema.Minimum {
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s invalid value %s. Must be greater than %v", path, raw, *schema.Minimum))
+			} else if value < *schema.Minimum {
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s invalid value %s. Must be greater than or equal to %v", path, raw, *schema.Minimum))
+			}
+		}
+		if schema.Maximum != nil {
+			if schema.ExclusiveMaximum && value >= *schema.Maximum {
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s invalid value %s. Must be less than %v", path, raw, *schema.Maximum))
+			} else if value > *schema.Maximum {
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s invalid value %s. Must be less than or equal to %v", path, raw, *schema.Maximum))
+			}
+		}
+		if schema.MultipleOf != nil && *schema.MultipleOf != 0 {
+			if !isMultipleOf(raw, value, *schema.MultipleOf) {
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s invalid value %s. Must be a multiple of %v", path, raw, *schema.MultipleOf))
+			}
+		}
+
+	case "string":
+		if subSchema.Default == nil {
+			break
+		}
+		length := int64(utf8.RuneCountInString(extractStringFromSchema(subSchema)))
+
+		if schema.MinLength != nil && length < *schema.MinLength {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s invalid value. Must be at least %d characters long got: %d", path, *schema.MinLength, length))
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s invalid value. Must be at most %d characters long got: %d", path, *schema.MaxLength, length))
+		}
+
+	case "array":
+		if subSchema.Items == nil {
+			break
+		}
+		items := subSchema.Items.JSONSchemas
+		count := int64(len(items))
+
+		if schema.MinItems != nil && count < *schema.MinItems {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s must have at least %d items got: %d", path, *schema.MinItems, count))
+		}
+		if schema.MaxItems != nil && count > *schema.MaxItems {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s must have at most %d items got: %d", path, *schema.MaxItems, count))
+		}
+		if schema.UniqueItems {
+			seen := []int{}
+			values := make([]interface{}, len(items))
+			for i, item := range items {
+				value, ok := literalValue(&item)
+				if !ok {
+					continue
+				}
+				values[i] = value
+				for _, first := range seen {
+					if reflect.DeepEqual(values[first], value) {
+						errors = append(errors, appendLocationIfKnownf(&item, "%s[%d] duplicate of %s[%d], items must be unique", path, i, path, first))
+						break
+					}
+				}
+				seen = append(seen, i)
+			}
+		}
+	}
+
+	return errors
+}
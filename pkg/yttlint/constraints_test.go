@@ -0,0 +1,141 @@
+package yttlint
+
+import (
+	"testing"
+)
+
+func TestEnum(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "enum",
+		schema: `{"type": "object", "properties": {
+			"imagePullPolicy": {"type": "string", "enum": ["Always", "IfNotPresent", "Never"]},
+			"restartPolicy": {"type": "string", "enum": ["Always", "OnFailure", "Never"]},
+			"replicas": {"type": "integer", "enum": [1, 3]},
+			"computed": {"type": "string", "enum": ["a", "b"]}
+		}}`,
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"imagePullPolicy: Alwyas\n" +
+			"restartPolicy: Never\n" +
+			"replicas: 2\n" +
+			"computed: #@ data.values.computed + \"\"\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: `.imagePullPolicy invalid value "Alwyas". Expected one of: "Always", "IfNotPresent", "Never". Did you mean: Always?`,
+			Pos: "test:2",
+		}, {
+			Msg: `.replicas invalid value 2. Expected one of: 1, 3`,
+			Pos: "test:4",
+		}},
+		pedanticErrors: []LinterError{{
+			Msg: `.computed expected one of "a", "b" got a computed value`,
+			Pos: "test:5",
+		}},
+	}})
+}
+
+func TestBounds(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "bounds",
+		schema: `{"type": "object", "properties": {
+			"replicas": {"type": "integer", "minimum": 0},
+			"containerPort": {"type": "integer", "minimum": 0, "exclusiveMinimum": true, "maximum": 65536, "exclusiveMaximum": true},
+			"hostPort": {"type": "integer", "maximum": 65535},
+			"step": {"type": "integer", "multipleOf": 5},
+			"name": {"type": "string", "minLength": 1, "maxLength": 5},
+			"empty": {"type": "string", "minLength": 1},
+			"args": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2, "uniqueItems": true},
+			"command": {"type": "array", "items": {"type": "string"}, "minItems": 1}
+		}}`,
+		document: "replicas: -1\n" +
+			"containerPort: 0\n" +
+			"hostPort: 65536\n" +
+			"step: 7\n" +
+			"name: too-long\n" +
+			"empty: \"\"\n" +
+			"args:\n" +
+			"- a\n" +
+			"- b\n" +
+			"- a\n" +
+			"command: []\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".replicas invalid value -1. Must be greater than or equal to 0",
+			Pos: "test:1",
+		}, {
+			Msg: ".containerPort invalid value 0. Must be greater than 0",
+			Pos: "test:2",
+		}, {
+			Msg: ".hostPort invalid value 65536. Must be less than or equal to 65535",
+			Pos: "test:3",
+		}, {
+			Msg: ".step invalid value 7. Must be a multiple of 5",
+			Pos: "test:4",
+		}, {
+			Msg: ".name invalid value. Must be at most 5 characters long got: 8",
+			Pos: "test:5",
+		}, {
+			Msg: ".empty invalid value. Must be at least 1 characters long got: 0",
+			Pos: "test:6",
+		}, {
+			Msg: ".args must have at most 2 items got: 3",
+			Pos: "test:7",
+		}, {
+			Msg: ".args[2] duplicate of .args[0], items must be unique",
+			Pos: "test:10",
+		}, {
+			Msg: ".command must have at least 1 items got: 0",
+			Pos: "test:11",
+		}},
+		pedanticErrors: []LinterError{},
+	}, {
+		name: "fractional multiples",
+		schema: `{"type": "object", "properties": {
+			"cpu": {"type": "number", "multipleOf": 0.1},
+			"price": {"type": "number", "multipleOf": 0.01},
+			"memory": {"type": "number", "multipleOf": 0.1}
+		}}`,
+		document: "cpu: 0.3\n" +
+			"price: 1.15\n" +
+			"memory: 0.35\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".memory invalid value 0.35. Must be a multiple of 0.1",
+			Pos: "test:3",
+		}},
+		pedanticErrors: []LinterError{},
+	}})
+}
+
+func TestUniqueItems(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "unique items",
+		schema: `{"type": "object", "properties": {
+			"ratios": {"type": "array", "items": {"type": "number"}, "uniqueItems": true},
+			"ports": {"type": "array", "items": {}, "uniqueItems": true}
+		}}`,
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"ratios:\n" +
+			"- 1\n" +
+			"- 1.0\n" +
+			"ports:\n" +
+			"- name: http\n" +
+			"  port: 80\n" +
+			"- port: 80\n" +
+			"  name: http\n" +
+			"- name: https\n" +
+			"  port: 443\n" +
+			"- [http, 80]\n" +
+			"- [80, http]\n" +
+			"- [http, 80]\n" +
+			"- #@ data.values.port\n" +
+			"- #@ data.values.port\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".ratios[1] duplicate of .ratios[0], items must be unique",
+			Pos: "test:4",
+		}, {
+			Msg: ".ports[1] duplicate of .ports[0], items must be unique",
+			Pos: "test:8",
+		}, {
+			Msg: ".ports[5] duplicate of .ports[3], items must be unique",
+			Pos: "test:14",
+		}},
+		pedanticErrors: []LinterError{},
+	}})
+}
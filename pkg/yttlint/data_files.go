@@ -0,0 +1,167 @@
+package yttlint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/template/core"
+	"go.starlark.net/starlark"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+// dataFiles implements data.read and data.list. Just like ytt resolves paths relative to the files passed via -f,
+// ytt-lint resolves them relative to the folder of the linted file or, if they start with /, to the root folder.
+type dataFiles struct {
+	root       string
+	dir        string
+	isExcluded func(path string) bool
+	usage      *dataValuesUsage
+}
+
+func (l *Linter) dataFiles(m modules, usage *dataValuesUsage) dataFiles {
+	result := dataFiles{root: m.root, dir: m.dir, isExcluded: l.IsExcluded, usage: usage}
+	if result.isExcluded == nil {
+		result.isExcluded = func(string) bool { return false }
+	}
+	return result
+}
+
+func (d dataFiles) members() starlark.StringDict {
+	return starlark.StringDict{
+		"read": starlark.NewBuiltin("data.read", core.ErrWrapper(d.read)),
+		"list": starlark.NewBuiltin("data.list", core.ErrWrapper(d.list)),
+	}
+}
+
+// read returns the content of a file, missing files are reported and result in a computed string, so linting
+// continues after the call
+func (d dataFiles) read(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if args.Len() != 1 {
+		return starlark.None, fmt.Errorf("expected exactly one argument")
+	}
+	if _, ok := args.Index(0).(*magic.MagicType); ok {
+		return &magic.MagicType{CouldBeString: true}, nil
+	}
+
+	path, err := core.NewStarlarkValue(args.Index(0)).AsString()
+	if err != nil {
+		return starlark.None, err
+	}
+
+	data, err := d.FileData(path)
+	if err != nil {
+		d.usage.report(err.Error())
+		return &magic.MagicType{CouldBeString: true}, nil
+	}
+	return starlark.String(string(data)), nil
+}
+
+func (d dataFiles) list(thread *starlark.Thread, f *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if args.Len() > 1 {
+		return starlark.None, fmt.Errorf("expected exactly zero or one argument")
+	}
+
+	path := ""
+	if args.Len() == 1 {
+		if _, ok := args.Index(0).(*magic.MagicType); ok {
+			return magic.ListOf(&magic.MagicType{CouldBeString: true}), nil
+		}
+		pathStr, err := core.NewStarlarkValue(args.Index(0)).AsString()
+		if err != nil {
+			return starlark.None, err
+		}
+		path = pathStr
+	}
+
+	paths, err := d.FilePaths(path)
+	if err != nil {
+		d.usage.report(err.Error())
+		return magic.ListOf(&magic.MagicType{CouldBeString: true}), nil
+	}
+
+	result := []starlark.Value{}
+	for _, path := range paths {
+		result = append(result, starlark.String(path))
+	}
+	return starlark.NewList(result), nil
+}
+
+// FilePaths lists all files below the directory path, which are neither ignored nor part of a private library
+func (d dataFiles) FilePaths(path string) ([]string, error) {
+	dir, err := d.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("Expected to find directory '%s'", path)
+	}
+
+	result := []string{}
+	err = filepath.Walk(dir, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".ytt-lint" || info.Name() == "_ytt_lib") {
+			return filepath.SkipDir
+		}
+		if d.isExcluded(filename) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		// just like ytt the paths are relative to the folder they are resolved against, so they can be passed to
+		// data.read
+		base := filepath.Join(d.root, filepath.FromSlash(d.dir))
+		if strings.HasPrefix(path, "/") {
+			base = d.root
+		}
+		rel, err := filepath.Rel(base, filename)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(path, "/") {
+			rel = "/" + rel
+		}
+		result = append(result, rel)
+		return nil
+	})
+	return result, err
+}
+
+// FileData reads a file
+func (d dataFiles) FileData(path string) ([]byte, error) {
+	filename, err := d.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(filename); err != nil || info.IsDir() || d.isExcluded(filename) {
+		return nil, fmt.Errorf("Expected to find file '%s' (hint: only files inside the root folder, which are not ignored, are available)", path)
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// resolve joins path with the folder of the linted file or the root folder, paths leaving the root folder are
+// rejected
+func (d dataFiles) resolve(path string) (string, error) {
+	if d.root == "" {
+		return "", fmt.Errorf("Expected path '%s' to be inside the root folder, but there is none (hint: pass --root)", path)
+	}
+	filename := filepath.Join(d.root, filepath.FromSlash(d.dir), filepath.FromSlash(path))
+	if strings.HasPrefix(path, "/") {
+		filename = filepath.Join(d.root, filepath.FromSlash(path))
+	}
+	rel, err := filepath.Rel(d.root, filename)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Expected path '%s' to be inside the root folder", path)
+	}
+	return filename, nil
+}
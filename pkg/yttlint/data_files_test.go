@@ -0,0 +1,53 @@
+package yttlint
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDataFiles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	linter := &Linter{
+		Root: "../../examples/data-files",
+		IsExcluded: func(path string) bool {
+			return filepath.Base(path) == "secret.properties"
+		},
+	}
+
+	files, err := linter.dataFiles(linter.modules("test"), nil).FilePaths("")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(Equal([]string{"config/logging.properties", "nginx.conf"}))
+
+	files, err = linter.dataFiles(linter.modules("test"), nil).FilePaths("/config")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(Equal([]string{"/config/logging.properties"}))
+
+	errors := linter.Lint("#@ load(\"@ytt:data\", \"data\")\n"+
+		"apiVersion: v1\n"+
+		"kind: ConfigMap\n"+
+		"metadata:\n"+
+		"  name: config\n"+
+		"data:\n"+
+		"  nginx.conf: #@ data.read(\"nginx.conf\")\n"+
+		"  #@ for file in data.list(\"config\"):\n"+
+		"  #@yaml/text-templated-strings\n"+
+		"  (@= file @): #@ data.read(file)\n"+
+		"  #@ end\n"+
+		"  secret: #@ data.read(\"config/secret.properties\")\n"+
+		"  missing: #@ data.read(\"missing.conf\")\n"+
+		"  computed: #@ data.read(data.values.file)\n"+
+		"  outside: #@ data.read(\"../profiles/values.yaml\")\n", "test", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "Expected to find file 'config/secret.properties' (hint: only files inside the root folder, which are not ignored, are available)",
+		Pos: "test:12",
+	}, {
+		Msg: "Expected to find file 'missing.conf' (hint: only files inside the root folder, which are not ignored, are available)",
+		Pos: "test:13",
+	}, {
+		Msg: "Expected path '../profiles/values.yaml' to be inside the root folder",
+		Pos: "test:15",
+	}}))
+}
@@ -0,0 +1,580 @@
+package yttlint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/template/core"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/k14s/ytt/pkg/yamltemplate"
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+	"go.starlark.net/starlark"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+// DataValuesOpts describes where the data values used for linting come from. The options mirror the ones of ytt.
+type DataValuesOpts struct {
+	// Files are ytt templates containing documents annotated with @data/values
+	Files []string
+	// PlainFiles are yaml files, all of their documents are used as data values
+	PlainFiles []string
+
+	// KVsFromStrings are given as all.key1.subkey=value, the value is a string
+	KVsFromStrings []string
+	// KVsFromYAML are given as all.key1.subkey=value, the value is parsed as yaml
+	KVsFromYAML []string
+	// EnvFromStrings are prefixes of environment variables (PREFIX_all__key1=value), the value is a string
+	EnvFromStrings []string
+	// EnvFromYAML are prefixes of environment variables (PREFIX_all__key1=value), the value is parsed as yaml
+	EnvFromYAML []string
+
+	// EnvironFunc defaults to os.Environ
+	EnvironFunc func() []string
+}
+
+var dataValuesAnnotationRegexp = regexp.MustCompile(`(?m)^\s*#@\s*data/values(-schema)?(\s|$)`)
+
+// FindDataValuesFiles lists all yaml files below root which contain a document annotated with @data/values or
+// @data/values-schema
+func FindDataValuesFiles(root string, isExcluded func(path string) bool) ([]string, error) {
+	result := []string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".ytt-lint" { // e.g. profiles are no default data values
+			return filepath.SkipDir
+		}
+		if isExcluded(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || (!strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml")) {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if dataValuesAnnotationRegexp.Match(data) {
+			result = append(result, path)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// DataValues are the data values used for linting
+type DataValues struct {
+	Values *yamlmeta.Document
+	// Schema describes the data values, it is nil if there is no document annotated with @data/values-schema
+	Schema *v1.JSONSchemaProps
+	// Errors are problems of the schema and data values violating it
+	Errors []LinterError
+}
+
+// LoadDataValues evaluates and overlays all data values in the same order as ytt does.
+// If there are no data values at all, nil is returned.
+func LoadDataValues(opts DataValuesOpts) (*DataValues, error) {
+	valuesFiles := []dataValuesFile{}
+	schemaDocs := []*yamlmeta.Document{}
+
+	for _, filename := range append(append([]string{}, opts.Files...), opts.PlainFiles...) {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		var docs, schemas []*yamlmeta.Document
+		if isPlainFile(filename, opts) {
+			docs, err = plainDataValues(string(data), filename)
+		} else {
+			docs, schemas, err = templateDataValues(string(data), filename)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Templating file '%s': %v", filename, err)
+		}
+		valuesFiles = append(valuesFiles, dataValuesFile{filename, docs})
+		schemaDocs = append(schemaDocs, schemas...)
+	}
+
+	result, err := newDataValues(schemaDocs, valuesFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	overlays, err := opts.asOverlays()
+	if err != nil {
+		return nil, err
+	}
+	if len(overlays) > 0 && result.Values == nil {
+		result.Values = &yamlmeta.Document{Value: &yamlmeta.Map{}, Position: filepos.NewUnknownPosition()}
+	}
+	violations, err := result.overlay(overlays)
+	if err != nil {
+		return nil, fmt.Errorf("Overlaying additional data values on top of data values from files: %v", err)
+	}
+	if len(violations) > 0 {
+		return nil, fmt.Errorf("Data value violates data values schema: %s", violations[0].Msg)
+	}
+
+	if result.Values == nil {
+		return nil, nil
+	}
+	return result, nil
+}
+
+type dataValuesFile struct {
+	filename string
+	docs     []*yamlmeta.Document
+}
+
+// newDataValues applies the schema and overlays the data values of all files
+func newDataValues(schemaDocs []*yamlmeta.Document, valuesFiles []dataValuesFile) (*DataValues, error) {
+	result := &DataValues{Errors: []LinterError{}}
+
+	// just like in ytt the schema is processed before any data values
+	if len(schemaDocs) > 0 {
+		schemaDoc, err := overlayDataValues(nil, schemaDocs)
+		if err != nil {
+			return nil, fmt.Errorf("Overlaying data values schemas: %v", err)
+		}
+		result.Schema, result.Errors = dataValuesSchema(schemaDoc)
+		result.Values = &yamlmeta.Document{
+			Value:    schemaDefaults(schemaDoc.Value, template.NewAnnotations(schemaDoc)),
+			Position: schemaDoc.Position,
+		}
+	}
+
+	for _, file := range valuesFiles {
+		violations, err := result.overlay(file.docs)
+		if err != nil {
+			return nil, fmt.Errorf("Overlaying data values of file '%s': %v", file.filename, err)
+		}
+		result.Errors = append(result.Errors, violations...)
+	}
+
+	return result, nil
+}
+
+// overlay validates docs against the schema, if there is one, and overlays them on top of the data values.
+// Documents violating the schema are not overlaid.
+func (dv *DataValues) overlay(docs []*yamlmeta.Document) ([]LinterError, error) {
+	violations := []LinterError{}
+	for _, doc := range docs {
+		if dv.Schema != nil {
+			subSchema := convert(doc.Value)
+			if subSchema.Description == "" {
+				subSchema.Description = doc.Position.AsCompactString()
+			}
+			docViolations := (&Linter{}).isSubset(nil, subSchema, dv.Schema, "data.values")
+			if len(docViolations) > 0 {
+				violations = append(violations, docViolations...)
+				continue
+			}
+			markReplacements(doc, dv.Schema)
+		}
+
+		var err error
+		dv.Values, err = overlayDataValues(dv.Values, []*yamlmeta.Document{doc})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return violations, nil
+}
+
+func isPlainFile(filename string, opts DataValuesOpts) bool {
+	for _, plainFile := range opts.PlainFiles {
+		if plainFile == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// templateDataValues evaluates a template and returns its documents annotated with @data/values and
+// @data/values-schema
+func templateDataValues(data, filename string) ([]*yamlmeta.Document, []*yamlmeta.Document, error) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{AssociatedName: filename})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	compiledTemplate, err := yamltemplate.NewTemplate(filename, yamltemplate.TemplateOpts{
+		IgnoreUnknownComments: true,
+	}).Compile(docSet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	linter := &Linter{}
+	result, errors := linter.evaluate(compiledTemplate, filename, nil)
+	if result == nil {
+		if len(errors) == 0 {
+			// e.g. comparisons of computed values, which are not reported
+			return nil, nil, fmt.Errorf("could not evaluate the template")
+		}
+		return nil, nil, fmt.Errorf("%s: %s", errors[0].Pos, errors[0].Msg)
+	}
+
+	valuesDocs, nonValuesDocs, err := yttlibrary.DataValues{
+		DocSet:    result,
+		MetasOpts: yamltemplate.MetasOpts{IgnoreUnknown: true},
+	}.Extract()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schemaDocs := []*yamlmeta.Document{}
+	for _, doc := range nonValuesDocs {
+		if hasDocumentAnnotation(doc, annotationDataValuesSchema) {
+			schemaDocs = append(schemaDocs, doc)
+		}
+	}
+	return valuesDocs, schemaDocs, nil
+}
+
+// plainDataValues returns all non empty documents of a yaml file
+func plainDataValues(data, filename string) ([]*yamlmeta.Document, error) {
+	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{AssociatedName: filename})
+	if err != nil {
+		return nil, err
+	}
+
+	docs := []*yamlmeta.Document{}
+	for _, doc := range docSet.Items {
+		if !doc.IsEmpty() {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// overlayDataValues overlays docs one after another on top of values, values may be nil
+func overlayDataValues(values *yamlmeta.Document, docs []*yamlmeta.Document) (*yamlmeta.Document, error) {
+	for _, doc := range docs {
+		if values == nil {
+			values = doc
+			continue
+		}
+
+		op := yttoverlay.OverlayOp{
+			Left:   &yamlmeta.DocumentSet{Items: []*yamlmeta.Document{values}},
+			Right:  &yamlmeta.DocumentSet{Items: []*yamlmeta.Document{doc}},
+			Thread: &starlark.Thread{Name: "data-values-pre-processing"},
+
+			ExactMatch: true,
+		}
+
+		newLeft, err := op.Apply()
+		if err != nil {
+			return nil, err
+		}
+		values = newLeft.(*yamlmeta.DocumentSet).Items[0]
+	}
+	return values, nil
+}
+
+// asOverlays converts the data values given as key-value pairs and environment variables into overlays.
+// Just like in ytt key-value pairs take precedence over environment variables.
+func (opts DataValuesOpts) asOverlays() ([]*yamlmeta.Document, error) {
+	plainValue := func(rawVal string) (interface{}, error) { return rawVal, nil }
+	yamlValue := func(rawVal string) (interface{}, error) {
+		docSet, err := yamlmeta.NewParser(yamlmeta.ParserOpts{}).ParseBytes([]byte(rawVal), "")
+		if err != nil {
+			return nil, fmt.Errorf("Deserializing YAML value: %v", err)
+		}
+		return docSet.Items[0].Value, nil
+	}
+
+	environ := opts.EnvironFunc
+	if environ == nil {
+		environ = os.Environ
+	}
+
+	result := []*yamlmeta.Document{}
+
+	for _, source := range []struct {
+		prefixes []string
+		value    func(string) (interface{}, error)
+	}{{opts.EnvFromStrings, plainValue}, {opts.EnvFromYAML, yamlValue}} {
+		for _, prefix := range source.prefixes {
+			for _, envVar := range environ() {
+				pieces := strings.SplitN(envVar, "=", 2)
+				if len(pieces) != 2 || !strings.HasPrefix(pieces[0], prefix+"_") {
+					continue
+				}
+				val, err := source.value(pieces[1])
+				if err != nil {
+					return nil, fmt.Errorf("Extracting data value from env variable '%s': %v", pieces[0], err)
+				}
+				// '__' is used instead of '.' since periods are not liked by shells
+				keyPieces := strings.Split(strings.TrimPrefix(pieces[0], prefix+"_"), "__")
+				result = append(result, buildDataValuesOverlay(keyPieces, val, "env var"))
+			}
+		}
+	}
+
+	for _, source := range []struct {
+		kvs   []string
+		value func(string) (interface{}, error)
+	}{{opts.KVsFromStrings, plainValue}, {opts.KVsFromYAML, yamlValue}} {
+		for _, kv := range source.kvs {
+			pieces := strings.SplitN(kv, "=", 2)
+			if len(pieces) != 2 {
+				return nil, fmt.Errorf("Expected data value '%s' to have format key=value", kv)
+			}
+			val, err := source.value(pieces[1])
+			if err != nil {
+				return nil, fmt.Errorf("Deserializing value for key '%s': %v", pieces[0], err)
+			}
+			result = append(result, buildDataValuesOverlay(strings.Split(pieces[0], "."), val, "kv arg"))
+		}
+	}
+
+	return result, nil
+}
+
+// buildDataValuesOverlay creates an overlay which replaces the value at the given key.
+// Just like in ytt a key suffixed with '+' may be missing in the data values.
+func buildDataValuesOverlay(keyPieces []string, value interface{}, desc string) *yamlmeta.Document {
+	resultMap := &yamlmeta.Map{}
+	currMap := resultMap
+	var lastMapItem *yamlmeta.MapItem
+
+	pos := filepos.NewPosition(1)
+	pos.SetFile(fmt.Sprintf("key '%s' (%s)", strings.Join(keyPieces, "."), desc))
+
+	for _, piece := range keyPieces {
+		newMap := &yamlmeta.Map{}
+		nodeAnns := template.NodeAnnotations{}
+
+		if strings.HasSuffix(piece, "+") {
+			piece = piece[:len(piece)-1]
+			nodeAnns[yttoverlay.AnnotationMatch] = template.NodeAnnotation{
+				Kwargs: []starlark.Tuple{{
+					starlark.String(yttoverlay.MatchAnnotationKwargMissingOK),
+					starlark.Bool(true),
+				}},
+			}
+		}
+
+		lastMapItem = &yamlmeta.MapItem{Key: piece, Value: newMap, Position: pos}
+		lastMapItem.SetAnnotations(nodeAnns)
+
+		currMap.Items = append(currMap.Items, lastMapItem)
+		currMap = newMap
+	}
+
+	lastMapItem.Value = yamlmeta.NewASTFromInterface(value)
+
+	// replacing a missing item is a noop, so an item which may be missing is merged instead
+	anns := template.NewAnnotations(lastMapItem)
+	if !anns.Has(yttoverlay.AnnotationMatch) {
+		anns[yttoverlay.AnnotationReplace] = template.NodeAnnotation{}
+		lastMapItem.SetAnnotations(anns)
+	}
+
+	return &yamlmeta.Document{Value: resultMap, Position: pos}
+}
+
+// dataValuesUsage tracks which data values are accessed while evaluating a template
+type dataValuesUsage struct {
+	linter           *Linter
+	thread           *starlark.Thread
+	compiledTemplate *template.CompiledTemplate
+	filename         string
+	// errors are reported during evaluation (e.g. undefined data values) without stopping it
+	errors []LinterError
+}
+
+// asStarlark converts data values to the struct accessible as data.values. Values, which are not known
+// (either because the key is missing or because its value is null), are MagicTypes. If there is a schema,
+// scalar values are MagicTypes of the type described by the schema.
+func (u *dataValuesUsage) asStarlark(value interface{}, path string, schema *v1.JSONSchemaProps) starlark.Value {
+	if schema != nil {
+		if typed, ok := typedMagic(schema); ok {
+			return typed
+		}
+	}
+
+	switch typedVal := value.(type) {
+	case *yamlmeta.Document:
+		return u.asStarlark(typedVal.Value, path, schema)
+	case *yamlmeta.Map:
+		data := orderedmap.NewMap()
+		for _, item := range typedVal.Items {
+			key := fmt.Sprint(item.Key)
+			data.Set(key, u.asStarlark(item.Value, path+"."+key, propertySchema(schema, key)))
+		}
+		return u.newStruct(data, path)
+	case *orderedmap.Map:
+		data := orderedmap.NewMap()
+		typedVal.Iterate(func(k, v interface{}) {
+			key := fmt.Sprint(k)
+			data.Set(key, u.asStarlark(v, path+"."+key, propertySchema(schema, key)))
+		})
+		return u.newStruct(data, path)
+	case *yamlmeta.Array:
+		items := []starlark.Value{}
+		for _, item := range typedVal.Items {
+			items = append(items, u.asStarlark(item.Value, path+"[]", itemSchema(schema)))
+		}
+		return starlark.NewList(items)
+	case []interface{}:
+		items := []starlark.Value{}
+		for _, item := range typedVal {
+			items = append(items, u.asStarlark(item, path+"[]", itemSchema(schema)))
+		}
+		return starlark.NewList(items)
+	case nil:
+		return magic.Any()
+	default:
+		return core.NewGoValue(value).AsStarlarkValue()
+	}
+}
+
+func propertySchema(schema *v1.JSONSchemaProps, key string) *v1.JSONSchemaProps {
+	if schema == nil {
+		return nil
+	}
+	prop, ok := schema.Properties[key]
+	if !ok {
+		return nil
+	}
+	return &prop
+}
+
+func itemSchema(schema *v1.JSONSchemaProps) *v1.JSONSchemaProps {
+	if schema == nil || schema.Items == nil {
+		return nil
+	}
+	return schema.Items.Schema
+}
+
+func (u *dataValuesUsage) newStruct(data *orderedmap.Map, path string) *magic.Struct {
+	result := magic.NewStruct(data)
+	result.Path = path
+	result.OnAttr = u.onAttr
+	return result
+}
+
+func (u *dataValuesUsage) onAttr(s *magic.Struct, name string, found bool) {
+	if found {
+		u.linter.markDataValueUsed(s.Path, name)
+		return
+	}
+
+	if u.thread.CallStackDepth() > 0 {
+		switch u.thread.CallFrame(0).Name {
+		case "hasattr", "getattr": // checking for a data value is fine
+			return
+		}
+	}
+
+	msg := fmt.Sprintf("data value %s.%s is not defined", s.Path, name)
+	alternatives := suggestAlternatives(name, s.AttrNames())
+	if len(alternatives) != 0 {
+		msg = fmt.Sprintf("%s. Did you mean: %s?", msg, strings.Join(alternatives, ", "))
+	}
+	u.report(msg)
+}
+
+// report records an error at the statement which is currently evaluated
+func (u *dataValuesUsage) report(msg string) {
+	u.errors = append(u.errors, LinterError{
+		Msg: msg,
+		Pos: u.currentPosition(),
+	})
+}
+
+// currentPosition maps the statement which is currently evaluated back to the line of the template
+func (u *dataValuesUsage) currentPosition() string {
+	stack := u.thread.CallStack()
+	for i := len(stack) - 1; i >= 0; i-- {
+		pos := stack[i].Pos
+		if pos.Filename() != u.filename {
+			continue
+		}
+		line := u.compiledTemplate.CodeAtLine(filepos.NewPosition(int(pos.Line)))
+		if line != nil && line.SourceLine != nil && line.SourceLine.Position.IsKnown() {
+			return fmt.Sprintf("%s:%d", u.filename, line.SourceLine.Position.Line())
+		}
+	}
+	return fmt.Sprintf("%s:1", u.filename)
+}
+
+func (l *Linter) markDataValueUsed(path, name string) {
+	if l.usedDataValues == nil {
+		l.usedDataValues = map[string]bool{}
+	}
+	l.usedDataValues[path+"."+name] = true
+	l.usedDataValues[path+".*"] = true
+}
+
+// UnusedDataValues reports data values, which were not accessed by any template linted so far.
+// Data values, which are only passed on as a whole (e.g. all labels), are not reported.
+func (l *Linter) UnusedDataValues() []LinterError {
+	return l.forEachProfile(l.unusedDataValues)
+}
+
+func (l *Linter) unusedDataValues() []LinterError {
+	errors := []LinterError{}
+	if !l.Pedantic || l.DataValues == nil {
+		return errors
+	}
+
+	reported := map[string]bool{}
+	var walk func(value interface{}, path string)
+	walk = func(value interface{}, path string) {
+		switch typedVal := value.(type) {
+		case *yamlmeta.Map:
+			for _, item := range typedVal.Items {
+				itemPath := path + "." + fmt.Sprint(item.Key)
+				if l.usedDataValues[itemPath] {
+					walk(item.Value, itemPath)
+					continue
+				}
+				if (path == "data.values" || l.usedDataValues[path+".*"]) && !reported[itemPath] {
+					reported[itemPath] = true
+					errors = append(errors, appendLocationIfKnownf(item, "data value %s is not used by any template", itemPath))
+				}
+			}
+		case *yamlmeta.Array:
+			for _, item := range typedVal.Items {
+				walk(item.Value, path+"[]")
+			}
+		}
+	}
+	walk(l.DataValues.Values.Value, "data.values")
+
+	return errors
+}
+
+// dataValuesErrors returns the problems of the data values located in the given file
+func (l *Linter) dataValuesErrors(filename string) []LinterError {
+	errors := []LinterError{}
+	if l.DataValues == nil {
+		return errors
+	}
+	for _, lintError := range l.DataValues.Errors {
+		if file, _, ok := splitPos(lintError.Pos); ok && file == filename {
+			errors = append(errors, lintError)
+		}
+	}
+	return errors
+}
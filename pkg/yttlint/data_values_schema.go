@@ -0,0 +1,192 @@
+package yttlint
+
+import (
+	"fmt"
+
+	"github.com/k14s/ytt/pkg/structmeta"
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/template/core"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/k14s/ytt/pkg/yamltemplate"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+// ytt v0.28 does not know about data values schemas, so the annotations are interpreted by ytt-lint
+const (
+	annotationDataValuesSchema structmeta.AnnotationName = "data/values-schema"
+	annotationSchemaType       structmeta.AnnotationName = "schema/type"
+	annotationSchemaNullable   structmeta.AnnotationName = "schema/nullable"
+	annotationSchemaDefault    structmeta.AnnotationName = "schema/default"
+)
+
+// hasDocumentAnnotation checks the metas of a document, because documents, which were not processed by a template,
+// have no annotations set
+func hasDocumentAnnotation(doc *yamlmeta.Document, name structmeta.AnnotationName) bool {
+	for _, meta := range doc.GetMetas() {
+		structMeta, err := yamltemplate.NewStructMetaFromMeta(meta, yamltemplate.MetasOpts{IgnoreUnknown: true})
+		if err != nil {
+			continue
+		}
+		for _, ann := range structMeta.Annotations {
+			if ann.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dataValuesSchema converts a document annotated with @data/values-schema into a json schema. Just like in ytt
+// the type of every entry is inferred from its value, arrays contain exactly one item describing all items.
+func dataValuesSchema(doc *yamlmeta.Document) (*v1.JSONSchemaProps, []LinterError) {
+	return schemaFromNode(doc.Value, template.NewAnnotations(doc), doc.Position.AsCompactString())
+}
+
+func schemaFromNode(value interface{}, anns template.NodeAnnotations, position string) (*v1.JSONSchemaProps, []LinterError) {
+	errors := []LinterError{}
+	schema := &v1.JSONSchemaProps{Description: position}
+
+	if anns.Has(annotationSchemaType) {
+		for _, kwarg := range anns.Kwargs(annotationSchemaType) {
+			name, _ := core.NewStarlarkValue(kwarg[0]).AsString()
+			isAny, err := core.NewStarlarkValue(kwarg[1]).AsBool()
+			if name != "any" || err != nil {
+				errors = append(errors, LinterError{
+					Msg: fmt.Sprintf("%s only supports the keyword argument any=True", annotationSchemaType),
+					Pos: position,
+				})
+			} else if isAny {
+				return schema, errors // typeless schemas accept any value
+			}
+		}
+	}
+	if anns.Has(annotationSchemaNullable) {
+		schema.Nullable = true
+	}
+
+	switch typedVal := value.(type) {
+	case *yamlmeta.Map:
+		schema.Type = "object"
+		schema.Properties = map[string]v1.JSONSchemaProps{}
+		for _, item := range typedVal.Items {
+			prop, propErrors := schemaFromNode(item.Value, template.NewAnnotations(item), item.Position.AsCompactString())
+			schema.Properties[fmt.Sprint(item.Key)] = *prop
+			errors = append(errors, propErrors...)
+		}
+	case *yamlmeta.Array:
+		schema.Type = "array"
+		if len(typedVal.Items) != 1 {
+			errors = append(errors, LinterError{
+				Msg: fmt.Sprintf("array in data values schema must contain exactly one item got: %d", len(typedVal.Items)),
+				Pos: position,
+			})
+			schema.Items = &v1.JSONSchemaPropsOrArray{Schema: &v1.JSONSchemaProps{}}
+			break
+		}
+		item := typedVal.Items[0]
+		itemSchema, itemErrors := schemaFromNode(item.Value, template.NewAnnotations(item), item.Position.AsCompactString())
+		schema.Items = &v1.JSONSchemaPropsOrArray{Schema: itemSchema}
+		errors = append(errors, itemErrors...)
+	case nil:
+		if !schema.Nullable {
+			errors = append(errors, LinterError{
+				Msg: fmt.Sprintf("null value in data values schema requires @%s or @%s any=True", annotationSchemaNullable, annotationSchemaType),
+				Pos: position,
+			})
+		}
+	default:
+		schema.Type = convert(value).Type
+	}
+
+	return schema, errors
+}
+
+// schemaDefaults creates the data values described by a data values schema. Arrays default to an empty array,
+// nullable values to null.
+func schemaDefaults(value interface{}, anns template.NodeAnnotations) interface{} {
+	if anns.Has(annotationSchemaDefault) {
+		args := anns.Args(annotationSchemaDefault)
+		if len(args) == 1 {
+			return yamlmeta.NewASTFromInterface(core.NewStarlarkValue(args[0]).AsGoValue())
+		}
+	}
+	if anns.Has(annotationSchemaNullable) {
+		return nil
+	}
+
+	switch typedVal := value.(type) {
+	case *yamlmeta.Map:
+		result := &yamlmeta.Map{Position: typedVal.Position}
+		for _, item := range typedVal.Items {
+			result.Items = append(result.Items, &yamlmeta.MapItem{
+				Key:      item.Key,
+				Value:    schemaDefaults(item.Value, template.NewAnnotations(item)),
+				Position: item.Position,
+			})
+		}
+		return result
+	case *yamlmeta.Array:
+		return &yamlmeta.Array{Position: typedVal.Position}
+	default:
+		return value
+	}
+}
+
+// markReplacements marks all entries of a data values document, which can't be merged into the schema defaults,
+// to replace the default instead. These are arrays, since their items have no overlay annotations, and values,
+// which may be null by default.
+func markReplacements(value interface{}, schema *v1.JSONSchemaProps) {
+	switch typedVal := value.(type) {
+	case *yamlmeta.Document:
+		markReplacements(typedVal.Value, schema)
+	case *yamlmeta.Map:
+		for _, item := range typedVal.Items {
+			prop := propertySchema(schema, fmt.Sprint(item.Key))
+			_, isArray := item.Value.(*yamlmeta.Array)
+			if !isArray && prop != nil && prop.Type != "" && !prop.Nullable {
+				markReplacements(item.Value, prop)
+				continue
+			}
+
+			anns := template.NewAnnotations(item)
+			if !hasOverlayAnnotation(anns) {
+				anns[yttoverlay.AnnotationReplace] = template.NodeAnnotation{}
+				item.SetAnnotations(anns)
+			}
+		}
+	}
+}
+
+func hasOverlayAnnotation(anns template.NodeAnnotations) bool {
+	for _, name := range []structmeta.AnnotationName{yttoverlay.AnnotationMerge, yttoverlay.AnnotationRemove,
+		yttoverlay.AnnotationReplace, yttoverlay.AnnotationAssert, yttoverlay.AnnotationMatch} {
+		if anns.Has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// typedMagic is a MagicType which can only be of the type described by schema
+func typedMagic(schema *v1.JSONSchemaProps) (*magic.MagicType, bool) {
+	var result *magic.MagicType
+	switch schema.Type {
+	case "string":
+		result = &magic.MagicType{CouldBeString: true}
+	case "integer":
+		result = &magic.MagicType{CouldBeInt: true}
+	case "number":
+		result = &magic.MagicType{CouldBeInt: true, CouldBeFloat: true}
+	case "boolean":
+		result = &magic.MagicType{CouldBeBool: true}
+	case "":
+		return magic.Any(), true
+	default:
+		return nil, false
+	}
+	result.CouldBeNone = schema.Nullable
+	return result, true
+}
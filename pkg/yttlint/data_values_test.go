@@ -0,0 +1,344 @@
+package yttlint
+
+import (
+	"testing"
+
+	"github.com/k14s/ytt/pkg/orderedmap"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	. "github.com/onsi/gomega"
+)
+
+func TestLoadDataValues(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	files, err := FindDataValuesFiles("../../examples/data-values", func(path string) bool { return false })
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(Equal([]string{
+		"../../examples/data-values/overlay.yml",
+		"../../examples/data-values/values.yaml",
+	}))
+
+	files, err = FindDataValuesFiles("../../examples/data-values", func(path string) bool {
+		return path == "../../examples/data-values/overlay.yml"
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(Equal([]string{"../../examples/data-values/values.yaml"}))
+
+	values, err := LoadDataValues(DataValuesOpts{
+		Files:          files,
+		PlainFiles:     []string{"../../examples/data-values/override.txt"},
+		KVsFromStrings: []string{"name=from-kv", "labels.tier+=backend"},
+		KVsFromYAML:    []string{"labels.app=42"},
+		EnvFromYAML:    []string{"DV"},
+		EnvironFunc: func() []string {
+			return []string{"DV_replicas=3", "DV_labels__app=from-env", "OTHER_name=ignored"}
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(orderedmap.Conversion{Object: values.Values.AsInterface()}.AsUnorderedStringMaps()).To(Equal(map[string]interface{}{
+		"name":     "from-kv",
+		"replicas": 3,
+		"labels": map[string]interface{}{
+			"app":  42,
+			"tier": "backend",
+		},
+	}))
+
+	_, err = LoadDataValues(DataValuesOpts{
+		Files:          files,
+		KVsFromStrings: []string{"unknown=value"},
+	})
+	g.Expect(err).To(HaveOccurred())
+
+	values, err = LoadDataValues(DataValuesOpts{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values).To(BeNil())
+}
+
+func TestUnusedDataValues(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dataValues := inlineDataValues(g, "#@data/values\n"+
+		"---\n"+
+		"name: my-app\n"+
+		"replicas: 1\n"+
+		"labels:\n"+
+		"  app: my-app\n"+
+		"ports:\n"+
+		"- name: http\n"+
+		"  port: 80\n")
+
+	linter := &Linter{
+		Pedantic:   true,
+		DataValues: dataValues,
+	}
+	noValidation := func(*yamlmeta.DocumentSet) []LinterError { return nil }
+
+	errors := linter.lintBranches("#@ load(\"@ytt:data\", \"data\")\n"+
+		"name: #@ data.values.name\n"+
+		"labels: #@ data.values.labels\n", "first", noValidation)
+	g.Expect(errors).To(BeEmpty())
+
+	errors = linter.lintBranches("#@ load(\"@ytt:data\", \"data\")\n"+
+		"ports:\n"+
+		"#@ for port in data.values.ports:\n"+
+		"- #@ port.port\n"+
+		"#@ end\n", "second", noValidation)
+	g.Expect(errors).To(BeEmpty())
+
+	g.Expect(linter.UnusedDataValues()).To(ConsistOf([]LinterError{{
+		Msg: "data value data.values.replicas is not used by any template",
+		Pos: "values:4",
+	}, {
+		Msg: "data value data.values.ports[].name is not used by any template",
+		Pos: "values:8",
+	}}))
+
+	linter.Pedantic = false
+	g.Expect(linter.UnusedDataValues()).To(BeEmpty())
+}
+
+// inlineDataValues loads the data values and data values schemas of a template
+func inlineDataValues(g *GomegaWithT, data string) *DataValues {
+	docs, schemaDocs, err := templateDataValues(data, "values")
+	g.Expect(err).NotTo(HaveOccurred())
+	dataValues, err := newDataValues(schemaDocs, []dataValuesFile{{"values", docs}})
+	g.Expect(err).NotTo(HaveOccurred())
+	return dataValues
+}
+
+func TestComputedDataValues(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// the failing comparison is not reported, but still has to fail loading the data values
+	_, _, err := templateDataValues("#@ load(\"@ytt:data\", \"data\")\n"+
+		"#@data/values\n"+
+		"---\n"+
+		"#@ if data.values.replicas > 1:\n"+
+		"replicas: 1\n"+
+		"#@ end\n", "values")
+	g.Expect(err).To(MatchError("could not evaluate the template"))
+}
+
+func TestDataValuesValidation(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "data values",
+		schema: `{"type": "object", "properties": {
+			"name": {"type": "string"},
+			"replicas": {"type": "integer"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"ports": {"type": "array", "items": {"type": "integer"}},
+			"image": {"type": "string"},
+			"unknown": {"type": "string"}
+		}}`,
+		dataValues: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"#@data/values\n" +
+			"---\n" +
+			"name: my-app\n" +
+			"replicas: \"3\"\n" +
+			"image: ~\n" +
+			"labels:\n" +
+			"  app: my-app\n" +
+			"  tier: 1\n" +
+			"ports:\n" +
+			"- name: http\n" +
+			"  port: 80\n" +
+			"#@data/values\n" +
+			"---\n" +
+			"name: #@ \"{}-{}\".format(data.values.name, 2)\n",
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"name: #@ data.values.nmae if hasattr(data.values, \"extra\") else data.values.name\n" +
+			"replicas: #@ data.values.replicas\n" +
+			"labels: #@ data.values.labels\n" +
+			"ports:\n" +
+			"#@ for port in data.values.ports:\n" +
+			"- #@ port.port\n" +
+			"- #@ port.name\n" +
+			"#@ end\n" +
+			"image: #@ data.values.image\n" +
+			"unknown: #@ data.values.unknown\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".replicas expected integer got: string",
+			Pos: "test:3",
+		}, {
+			Msg: ".labels.tier expected string got: integer",
+			Pos: "test:4",
+		}, {
+			Msg: ".ports[1] expected integer got: string",
+			Pos: "test:8",
+		}, {
+			Msg: "data value data.values.nmae is not defined. Did you mean: name, image?",
+			Pos: "test:2",
+		}, {
+			Msg: "data value data.values.unknown is not defined",
+			Pos: "test:11",
+		}},
+		pedanticErrors: []LinterError{{
+			Msg: ".name expected string got a computed value. Tip: use str(...) to convert to string",
+			Pos: "test:2",
+		}, {
+			Msg: ".image expected string got a computed value. Tip: use str(...) to convert to string",
+			Pos: "test:10",
+		}, {
+			Msg: ".unknown expected string got a computed value. Tip: use str(...) to convert to string",
+			Pos: "test:11",
+		}},
+	}, {
+		name: "data values schema",
+		schema: `{"type": "object", "properties": {
+			"name": {"type": "string"},
+			"replicas": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"image": {"type": "string"},
+			"config": {"type": "string"}
+		}}`,
+		dataValues: "#@data/values-schema\n" +
+			"---\n" +
+			"name: my-app\n" +
+			"replicas: 1\n" +
+			"#@schema/nullable\n" +
+			"image: \"\"\n" +
+			"#@schema/type any=True\n" +
+			"config: \"\"\n",
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"name: #@ data.values.name\n" +
+			"replicas: #@ data.values.name\n" +
+			"ratio: #@ data.values.replicas\n" +
+			"image: #@ data.values.image\n" +
+			"config: #@ data.values.config\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".replicas expected integer got: computed string",
+			Pos: "test:3",
+		}},
+		pedanticErrors: []LinterError{{
+			Msg: ".image expected string got a computed value, which might be None",
+			Pos: "test:5",
+		}, {
+			Msg: ".config expected string got a computed value. Tip: use str(...) to convert to string",
+			Pos: "test:6",
+		}},
+	}, {
+		name: "computed types",
+		schema: `{"type": "object", "properties": {
+			"enabled": {"type": "boolean"},
+			"flag": {"type": "boolean"},
+			"label": {"type": "string"},
+			"labels": {"type": "object"},
+			"names": {"type": "array", "items": {"type": "string"}},
+			"ports": {"type": "array", "items": {"type": "integer"}},
+			"ratio": {"type": "number"},
+			"optional": {"type": "string", "nullable": true},
+			"count": {"type": "integer"}
+		}}`,
+		dataValues: "#@data/values-schema\n" +
+			"---\n" +
+			"name: my-app\n" +
+			"enabled: true\n" +
+			"#@schema/nullable\n" +
+			"suffix: \"\"\n" +
+			"#@schema/type any=True\n" +
+			"config: \"\"\n",
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"enabled: #@ data.values.enabled\n" +
+			"flag: #@ data.values.config\n" +
+			"label: #@ data.values.name.upper()\n" +
+			"labels: #@ data.values.name + \"-labels\"\n" +
+			"names: #@ data.values.name.split(\"-\")\n" +
+			"ports: #@ [data.values.name]\n" +
+			"ratio: #@ len(data.values.name) / 2\n" +
+			"optional: #@ data.values.suffix\n" +
+			"count: #@ data.values.suffix\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".labels expected object got: computed string",
+			Pos: "test:5",
+		}, {
+			Msg: ".ports[0] expected integer got: computed string",
+			Pos: "test:7",
+		}, {
+			Msg: ".count expected integer got: computed string or None",
+			Pos: "test:10",
+		}},
+		pedanticErrors: []LinterError{{
+			Msg: ".flag expected boolean got a computed value. Tip: use bool(...) to convert to bool",
+			Pos: "test:3",
+		}},
+	}, {
+		name: "required value guards",
+		schema: `{"type": "object", "properties": {
+			"name": {"type": "string"}
+		}}`,
+		dataValues: "#@data/values\n" +
+			"---\n" +
+			"name: foo\n",
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"#@ load(\"@ytt:assert\", \"assert\")\n" +
+			"#@ if not data.values.name:\n" +
+			"#@   assert.fail(\"name is required\")\n" +
+			"#@ end\n" +
+			"name: #@ data.values.name\n",
+		nonPedanticErrors: []LinterError{},
+		pedanticErrors:    []LinterError{},
+	}})
+}
+
+func TestDataValuesSchema(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dataValues := inlineDataValues(g, "#@data/values-schema\n"+
+		"---\n"+
+		"name: my-app\n"+
+		"replicas: 1\n"+
+		"ratio: 0.5\n"+
+		"enabled: false\n"+
+		"ports:\n"+
+		"- name: http\n"+
+		"  port: 80\n"+
+		"#@schema/nullable\n"+
+		"image: \"\"\n"+
+		"#@schema/default [\"a\", \"b\"]\n"+
+		"hosts:\n"+
+		"- \"\"\n"+
+		"#@schema/type any=True\n"+
+		"config: ~\n"+
+		"invalid: ~\n"+
+		"#@data/values\n"+
+		"---\n"+
+		"replicas: \"3\"\n"+
+		"ports:\n"+
+		"- name: https\n"+
+		"  prot: 443\n"+
+		"#@data/values\n"+
+		"---\n"+
+		"name: other-app\n"+
+		"ratio: 1\n"+
+		"ports:\n"+
+		"- name: https\n"+
+		"  port: 443\n"+
+		"config:\n"+
+		"  anything: [1, 2]\n")
+
+	g.Expect(dataValues.Errors).To(ConsistOf([]LinterError{{
+		Msg: "null value in data values schema requires @schema/nullable or @schema/type any=True",
+		Pos: "values:17",
+	}, {
+		Msg: "data.values.replicas expected integer got: string",
+		Pos: "values:20",
+	}, {
+		Msg: "data.values.ports[0].prot additional properties are not permitted. Did you mean: port?",
+		Pos: "values:23",
+	}}))
+
+	g.Expect(orderedmap.Conversion{Object: dataValues.Values.AsInterface()}.AsUnorderedStringMaps()).To(Equal(map[string]interface{}{
+		"name":     "other-app",
+		"replicas": 1,
+		"ratio":    1,
+		"enabled":  false,
+		"ports": []interface{}{
+			map[string]interface{}{"name": "https", "port": 443},
+		},
+		"image":   nil,
+		"hosts":   []interface{}{"a", "b"},
+		"config":  map[string]interface{}{"anything": []interface{}{1, 2}},
+		"invalid": nil,
+	}))
+}
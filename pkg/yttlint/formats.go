@@ -0,0 +1,98 @@
+package yttlint
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// FormatChecker validates a literal string against a format
+type FormatChecker func(value string) error
+
+var formatCheckers = map[string]FormatChecker{
+	"date-time": func(value string) error {
+		_, err := time.Parse(time.RFC3339, value)
+		return err
+	},
+	"date": func(value string) error {
+		_, err := time.Parse("2006-01-02", value)
+		return err
+	},
+	"duration": func(value string) error {
+		_, err := time.ParseDuration(value)
+		return err
+	},
+	"quantity": func(value string) error {
+		_, err := resource.ParseQuantity(value)
+		return err
+	},
+	"uri": func(value string) error {
+		u, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" {
+			return fmt.Errorf("missing scheme")
+		}
+		return nil
+	},
+	"email": func(value string) error {
+		address, err := mail.ParseAddress(value)
+		if err != nil {
+			return err
+		}
+		if address.Address != value {
+			return fmt.Errorf("not a plain address")
+		}
+		return nil
+	},
+	"ipv4": func(value string) error {
+		ip := net.ParseIP(value)
+		if ip == nil || ip.To4() == nil || strings.Contains(value, ":") {
+			return fmt.Errorf("not an ipv4 address")
+		}
+		return nil
+	},
+	"ipv6": func(value string) error {
+		ip := net.ParseIP(value)
+		if ip == nil || !strings.Contains(value, ":") {
+			return fmt.Errorf("not an ipv6 address")
+		}
+		return nil
+	},
+	"byte": func(value string) error {
+		_, err := base64.StdEncoding.DecodeString(value)
+		return err
+	},
+}
+
+// RegisterFormat adds a checker for a string format or replaces an existing one
+func RegisterFormat(format string, checker FormatChecker) {
+	formatCheckers[format] = checker
+}
+
+func isCheckedFormat(format string) bool {
+	_, ok := formatCheckers[format]
+	return ok
+}
+
+func (l *Linter) validateFormat(subSchema, schema *v1.JSONSchemaProps, path string) []LinterError {
+	errors := make([]LinterError, 0)
+
+	checker, ok := formatCheckers[schema.Format]
+	if !ok || !isLiteral(subSchema) || subSchema.Type != "string" {
+		return errors
+	}
+
+	if checker(extractStringFromSchema(subSchema)) != nil {
+		errors = append(errors, appendLocationIfKnownf(subSchema, "%s invalid value. Expected to match format: %s", path, schema.Format))
+	}
+	return errors
+}
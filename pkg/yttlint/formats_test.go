@@ -0,0 +1,56 @@
+package yttlint
+
+import (
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "formats",
+		schema: `{"type": "object", "properties": {
+			"createdAt": {"type": "string", "format": "date-time"},
+			"timeout": {"type": "string", "format": "duration"},
+			"cpu": {"type": "string", "format": "quantity"},
+			"memory": {"type": "string", "format": "quantity"},
+			"replicas": {"type": "string", "format": "quantity"},
+			"url": {"type": "string", "format": "uri"},
+			"mail": {"type": "string", "format": "email"},
+			"ipv4": {"type": "string", "format": "ipv4"},
+			"ipv6": {"type": "string", "format": "ipv6"},
+			"data": {"type": "object", "additionalProperties": {"type": "string", "format": "byte"}}
+		}}`,
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"createdAt: yesterday\n" +
+			"timeout: 10m\n" +
+			"cpu: 1.5cores\n" +
+			"memory: #@ data.values.memory\n" +
+			"replicas: 3\n" +
+			"url: example.com/path\n" +
+			"mail: admin@example.com\n" +
+			"ipv4: 10.0.0.256\n" +
+			"ipv6: ::1\n" +
+			"data:\n" +
+			"  valid: aGVsbG8=\n" +
+			"  invalid: hello world\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".createdAt invalid value. Expected to match format: date-time",
+			Pos: "test:2",
+		}, {
+			Msg: ".cpu invalid value. Expected to match format: quantity",
+			Pos: "test:4",
+		}, {
+			Msg: ".url invalid value. Expected to match format: uri",
+			Pos: "test:7",
+		}, {
+			Msg: ".ipv4 invalid value. Expected to match format: ipv4",
+			Pos: "test:9",
+		}, {
+			Msg: ".data.invalid invalid value. Expected to match format: byte",
+			Pos: "test:13",
+		}},
+		pedanticErrors: []LinterError{{
+			Msg: ".memory expected string of format quantity got a computed value",
+			Pos: "test:5",
+		}},
+	}})
+}
@@ -0,0 +1,89 @@
+package yttlint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// qualifiedKeyPaths lists the path suffixes of maps whose keys have to be qualified names, like label and annotation keys
+var qualifiedKeyPaths = []string{
+	".metadata.labels",
+	".metadata.annotations",
+	".matchLabels",
+	".nodeSelector",
+}
+
+func requiresQualifiedKeys(path string) bool {
+	for _, suffix := range qualifiedKeyPaths {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateKeys checks the keys of label and annotation maps. Errors are reported at the line of the offending key.
+func validateKeys(subSchema *v1.JSONSchemaProps, path string) []LinterError {
+	errors := make([]LinterError, 0)
+
+	if subSchema.Type != "object" || !requiresQualifiedKeys(path) {
+		return errors
+	}
+
+	for _, key := range sortedKeys(subSchema.Properties) {
+		msgs := validation.IsQualifiedName(key)
+		if len(msgs) == 0 {
+			continue
+		}
+		val := subSchema.Properties[key]
+		errors = append(errors, appendLocationIfKnownf(keyLocation(&val), "%s invalid key %q: %s", path, key, strings.Join(msgs, ", ")))
+	}
+
+	return errors
+}
+
+// matchPatternProperties validates val against all patternProperties matching key. It returns whether any pattern
+// matched.
+func (l *Linter) matchPatternProperties(defs v1.JSONSchemaDefinitions, val *v1.JSONSchemaProps, schema *v1.JSONSchemaProps, path, key string) (bool, []LinterError) {
+	errors := make([]LinterError, 0)
+	matched := false
+
+	for _, pattern := range sortedKeys(schema.PatternProperties) {
+		regex, err := regexp.Compile("^" + pattern + "$")
+		if err != nil {
+			errors = append(errors, appendLocationIfKnownf(keyLocation(val), "%s could not validate key, schema contains invalid pattern: %s", path, pattern))
+			continue
+		}
+		if !regex.MatchString(key) {
+			continue
+		}
+		matched = true
+		patternSchema := schema.PatternProperties[pattern]
+		errors = append(errors, l.isSubset(defs, val, &patternSchema, fmt.Sprintf("%s.%s", path, key))...)
+	}
+
+	return matched, errors
+}
+
+// keyLocation returns a schema carrying the position of the key val belongs to. Keys defined in several branches
+// are reported at the first one.
+func keyLocation(val *v1.JSONSchemaProps) *v1.JSONSchemaProps {
+	if val.Description == "" && len(val.AnyOf) > 0 {
+		return &val.AnyOf[0]
+	}
+	return val
+}
+
+func sortedKeys(properties map[string]v1.JSONSchemaProps) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,30 @@
+package yttlint
+
+import (
+	"testing"
+)
+
+func TestPatternProperties(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "pattern properties",
+		schema: `{"type": "object", "properties": {
+			"name": {"type": "string"}
+		}, "patternProperties": {
+			"x-.*": {"type": "string"},
+			"[0-9]+": {"type": "integer"}
+		}}`,
+		document: "name: pattern\n" +
+			"x-vendor: acme\n" +
+			"x-revision: 3\n" +
+			"404: 7\n" +
+			"other: value\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".x-revision expected string got: integer",
+			Pos: "test:3",
+		}, {
+			Msg: ".other additional properties are not permitted",
+			Pos: "test:5",
+		}},
+		pedanticErrors: []LinterError{},
+	}})
+}
@@ -6,12 +6,21 @@ type ErrorCode string
 
 const (
 	ErrorCodeHelm = "HELM"
+
+	// codes of the static analysis of starlark code
+	ErrorCodeUnusedLoad      ErrorCode = "UNUSED_LOAD"
+	ErrorCodeUnusedVariable  ErrorCode = "UNUSED_VARIABLE"
+	ErrorCodeUnusedFunction  ErrorCode = "UNUSED_FUNCTION"
+	ErrorCodeShadowedBuiltin ErrorCode = "SHADOWED_BUILTIN"
+	ErrorCodeUnreachableCode ErrorCode = "UNREACHABLE_CODE"
 )
 
 type LinterError struct {
 	Msg  string    `json:"msg"`
 	Pos  string    `json:"pos"`
 	Code ErrorCode `json:"code"`
+	// Profiles lists the data values profiles the error occurs with, it is empty if no profiles are used
+	Profiles []string `json:"profiles,omitempty"`
 }
 
 func lintErrorf(format string, args ...interface{}) LinterError {
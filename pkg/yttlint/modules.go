@@ -0,0 +1,209 @@
+package yttlint
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/workspace"
+)
+
+// modules resolves the paths passed to load() just like ytt resolves them for the files passed via -f: relative to
+// the folder of the loading file, relative to the root folder if they start with / and relative to the _ytt_lib
+// folder next to the loading file for @name:path
+type modules struct {
+	root string
+	// dir is the folder of the linted file relative to root, it is empty for files directly inside of root or
+	// outside of it
+	dir string
+}
+
+func (l *Linter) modules(filename string) modules {
+	root := l.projectRoot(filename)
+	dir, err := filepath.Rel(root, filepath.Dir(filename))
+	if err != nil || dir == "." || dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+		// e.g. stdin linted with a root folder behaves like a file inside of it
+		return modules{root: root}
+	}
+	return modules{root: root, dir: filepath.ToSlash(dir)}
+}
+
+// stdinFilename is the name of files read from stdin without a path
+const stdinFilename = "-"
+
+// projectRoot is the root folder if set, otherwise it is detected. Files read from stdin without a path have no root
+// folder, as the working directory might contain lots of unrelated files.
+func (l *Linter) projectRoot(filename string) string {
+	if l.Root != "" {
+		return l.Root
+	}
+	if filename == stdinFilename {
+		return ""
+	}
+	return FindProjectRoot(filepath.Dir(filename))
+}
+
+// FindProjectRoot returns the closest folder containing an ignore file or profiles (see README.md). If there is none,
+// the working directory is returned if it contains dir, as ytt is usually run from the root of a project, otherwise
+// dir is returned. All files below the root folder are read once per Linter (to resolve load(), data.read and
+// data.list), so a marker or an explicit root limits this to the files of the project.
+func FindProjectRoot(dir string) string {
+	for current := dir; ; current = filepath.Join(current, "..") {
+		for _, marker := range []string{"ignore", "profiles"} {
+			if _, err := os.Stat(filepath.Join(current, ".ytt-lint", marker)); err == nil {
+				return current
+			}
+		}
+		abs, err := filepath.Abs(current)
+		if err != nil || filepath.Dir(abs) == abs {
+			break
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return dir
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil || !isInside(wd, abs) {
+		return dir
+	}
+	if filepath.IsAbs(dir) {
+		return wd
+	}
+	return "."
+}
+
+// isInside reports whether the path is the folder dir or inside of it
+func isInside(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// libraries returns the modules of the linted file, the root library containing all files below root and the
+// library of the linted file
+func (l *Linter) libraries(filename string) (modules, workspace.LibraryExecutionContext, error) {
+	m := l.modules(filename)
+	libraryCtx, err := l.libraryContext(m)
+	if err != nil {
+		// e.g. a file inside of _ytt_lib, which only sees the files next to it
+		m = modules{root: filepath.Dir(filename)}
+		libraryCtx, err = l.libraryContext(m)
+	}
+	return m, libraryCtx, err
+}
+
+func (l *Linter) libraryContext(m modules) (workspace.LibraryExecutionContext, error) {
+	if l.libraryFiles == nil {
+		l.libraryFiles = map[string][]*files.File{}
+	}
+	libraryFiles, found := l.libraryFiles[m.root]
+	if !found && m.root != "" {
+		all, err := files.NewSortedFilesFromPaths([]string{m.root}, files.SymlinkAllowOpts{
+			AllowAll: true,
+		})
+		if err != nil {
+			return workspace.LibraryExecutionContext{}, err
+		}
+		for _, file := range all {
+			if l.IsExcluded != nil && l.IsExcluded(filepath.Join(m.root, filepath.FromSlash(file.RelativePath()))) {
+				continue
+			}
+			libraryFiles = append(libraryFiles, file)
+		}
+		l.libraryFiles[m.root] = libraryFiles
+	}
+
+	rootLib := workspace.NewRootLibrary(libraryFiles)
+	if m.dir == "" {
+		return workspace.LibraryExecutionContext{Current: rootLib, Root: rootLib}, nil
+	}
+	currentLib, err := rootLib.FindLibrary(m.dir)
+	if err != nil {
+		return workspace.LibraryExecutionContext{}, err
+	}
+	return workspace.LibraryExecutionContext{Current: currentLib, Root: rootLib}, nil
+}
+
+// alternative is a path ytt does not search for a module, but which is listed if the module is not found, as it might
+// be what was meant
+type alternative struct {
+	filename string
+	hint     string
+}
+
+// resolve checks whether module exists and returns it in the format understood by ytt. Relative paths leaving the
+// folder of the linted file are turned into paths relative to the root folder. If the module does not exist, all
+// paths searched for it are reported.
+func (m modules) resolve(module string) (string, error) {
+	var filename string
+	alternatives := []alternative{}
+	switch {
+	case strings.HasPrefix(module, "@"):
+		pieces := strings.SplitN(module[1:], ":", 2)
+		if len(pieces) != 2 {
+			// ytt explains the expected format
+			return module, nil
+		}
+		filename = path.Join(m.dir, "_ytt_lib", pieces[0], pieces[1])
+		// ytt only searches the _ytt_lib folder next to the loading file
+		for dir := m.dir; dir != ""; {
+			dir = parentDir(dir)
+			alternatives = append(alternatives, alternative{
+				filename: path.Join(dir, "_ytt_lib", pieces[0], pieces[1]),
+				hint:     fmt.Sprintf("only the files in '%s' can load it", filepath.Join(m.root, filepath.FromSlash(dir))),
+			})
+		}
+	case files.IsRootPath(module):
+		filename = path.Clean(files.StripRootPath(module))
+	default:
+		filename = path.Join(m.dir, module)
+		if strings.Contains(module, "..") {
+			module = files.MakeRootPath(filename)
+		} else if m.dir != "" {
+			alternatives = append(alternatives, alternative{
+				filename: path.Clean(module),
+				hint:     fmt.Sprintf("load it with '%s'", files.MakeRootPath(path.Clean(module))),
+			})
+		}
+	}
+
+	searchPath := filepath.Join(m.root, filepath.FromSlash(filename))
+	if filename == ".." || strings.HasPrefix(filename, "../") {
+		return "", fmt.Errorf("module not found, '%s' is outside of the root folder '%s'", searchPath, m.root)
+	}
+	if m.root == "" {
+		return "", fmt.Errorf("module not found, there is no root folder to search '%s' in (hint: pass --root)", filename)
+	}
+	if isFile(searchPath) {
+		return module, nil
+	}
+
+	searched := []string{searchPath}
+	hints := []string{}
+	for _, alt := range alternatives {
+		altPath := filepath.Join(m.root, filepath.FromSlash(alt.filename))
+		searched = append(searched, altPath)
+		if isFile(altPath) {
+			hints = append(hints, fmt.Sprintf(". Found '%s', %s", altPath, alt.hint))
+		}
+	}
+	return "", fmt.Errorf("module not found (searched: %s)%s", strings.Join(searched, ", "), strings.Join(hints, ""))
+}
+
+// parentDir returns the parent of a folder relative to the root folder, the root folder itself is ""
+func parentDir(dir string) string {
+	parent := path.Dir(dir)
+	if parent == "." {
+		return ""
+	}
+	return parent
+}
+
+func isFile(filename string) bool {
+	info, err := os.Stat(filename)
+	return err == nil && !info.IsDir()
+}
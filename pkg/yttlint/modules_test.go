@@ -0,0 +1,107 @@
+package yttlint
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestModules(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	root := "../../examples/load"
+	g.Expect(FindProjectRoot(root + "/config")).To(Equal(root))
+	g.Expect(FindProjectRoot(root + "/config/_ytt_lib/labels")).To(Equal(root))
+
+	filename := root + "/config/configmap.yaml"
+	data, err := ioutil.ReadFile(filename)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	linter := &Linter{}
+	errors := linter.Lint(string(data), filename, false)
+	g.Expect(errors).To(BeEmpty())
+
+	errors = linter.Lint("#@ load(\"@labels:missing.star\", \"labels\")\nlabels: #@ labels()\n", filename, false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "cannot load @labels:missing.star: module not found (searched: " + root + "/config/_ytt_lib/labels/missing.star, " + root + "/_ytt_lib/labels/missing.star)",
+		Pos: filename + ":1",
+	}}))
+
+	errors = linter.Lint("#@ load(\"helpers.star\", \"name\")\nname: #@ name()\n", filename, false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "cannot load helpers.star: module not found (searched: " + root + "/config/helpers.star, " + root + "/helpers.star). Found '" + root + "/helpers.star', load it with '/helpers.star'",
+		Pos: filename + ":1",
+	}}))
+
+	errors = linter.Lint("#@ load(\"../../helpers.star\", \"name\")\nname: #@ name()\n", filename, false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "cannot load ../../helpers.star: module not found, '../../examples/helpers.star' is outside of the root folder '" + root + "'",
+		Pos: filename + ":1",
+	}}))
+
+	// an explicit root folder takes precedence over the detected one
+	linter = &Linter{Root: root + "/config"}
+	errors = linter.Lint("#@ load(\"/helpers.star\", \"name\")\nname: #@ name()\n", filename, false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "cannot load /helpers.star: module not found (searched: " + root + "/config/helpers.star)",
+		Pos: filename + ":1",
+	}}))
+}
+
+func TestModulesWithUnreadableRoot(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	root, err := ioutil.TempDir("", "ytt-lint-root")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(root)
+	// ytt only reads regular files, symlinks and pipes
+	listener, err := net.Listen("unix", filepath.Join(root, "socket"))
+	g.Expect(err).NotTo(HaveOccurred())
+	defer listener.Close()
+
+	filename := filepath.Join(root, "configmap.yaml")
+	linter := &Linter{}
+	errors := linter.Lint("#@ name = \"test\"\nname: #@ name\n", filename, false)
+	g.Expect(errors).To(HaveLen(1))
+	g.Expect(errors[0].Msg).To(HavePrefix("could not read the files of the root folder: "))
+	g.Expect(errors[0].Pos).To(Equal(filename + ":1"))
+
+	// files read from stdin without a path do not read the working directory
+	g.Expect(linter.projectRoot("-")).To(BeEmpty())
+	errors = linter.Lint("#@ load(\"helpers.star\", \"name\")\nname: #@ name()\n", "-", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "cannot load helpers.star: module not found, there is no root folder to search 'helpers.star' in (hint: pass --root)",
+		Pos: "-:1",
+	}}))
+	errors = linter.Lint("#@ load(\"@ytt:data\", \"data\")\nname: #@ data.read(\"name.txt\")\n", "-", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "Expected path 'name.txt' to be inside the root folder, but there is none (hint: pass --root)",
+		Pos: "-:2",
+	}}))
+}
+
+func TestModulesWithoutMarker(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	root, err := ioutil.TempDir("", "ytt-lint-root")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(root)
+	g.Expect(os.MkdirAll(filepath.Join(root, "config"), os.ModePerm)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(root, "helpers.star"), []byte("def name():\n  return \"test\"\nend\n"), os.ModePerm)).To(Succeed())
+	template := "#@ load(\"../helpers.star\", \"name\")\nname: #@ name()\n"
+	g.Expect(ioutil.WriteFile(filepath.Join(root, "config", "configmap.yaml"), []byte(template), os.ModePerm)).To(Succeed())
+
+	// without a marker the working directory is the root folder, if it contains the linted file
+	t.Chdir(root)
+	g.Expect(FindProjectRoot("config")).To(Equal("."))
+	g.Expect(FindProjectRoot(filepath.Join(root, "config"))).To(Equal(root))
+	g.Expect(FindProjectRoot(os.TempDir())).To(Equal(os.TempDir()))
+
+	linter := &Linter{}
+	errors := linter.Lint(template, filepath.Join("config", "configmap.yaml"), false)
+	g.Expect(errors).To(BeEmpty())
+}
@@ -0,0 +1,61 @@
+package yttlint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// computedNulls maps the positions of map and array items, whose template expression evaluated to None, to the
+// expression, so that errors can explain where the null came from
+func computedNulls(value interface{}) map[string]string {
+	result := map[string]string{}
+	collectComputedNulls(value, result)
+	return result
+}
+
+func collectComputedNulls(value interface{}, result map[string]string) {
+	switch typedValue := value.(type) {
+	case *yamlmeta.Map:
+		for _, item := range typedValue.Items {
+			markComputedNull(item.Value, item.Metas, item.Position, result)
+			collectComputedNulls(item.Value, result)
+		}
+	case *yamlmeta.Array:
+		for _, item := range typedValue.Items {
+			markComputedNull(item.Value, item.Metas, item.Position, result)
+			collectComputedNulls(item.Value, result)
+		}
+	}
+}
+
+// markComputedNull records the template expression of a null item, which is the position of its converted schema
+func markComputedNull(value interface{}, metas []*yamlmeta.Meta, position *filepos.Position, result map[string]string) {
+	if value != nil || !position.IsKnown() {
+		return
+	}
+	for _, meta := range metas {
+		if meta.Position.IsKnown() && meta.Position.Line() == position.Line() && strings.HasPrefix(meta.Data, "@ ") {
+			result[position.AsCompactString()] = strings.TrimSpace(meta.Data[2:])
+		}
+	}
+}
+
+// describeType returns the type of subSchema as used in error messages
+func (l *Linter) describeType(subSchema *v1.JSONSchemaProps) string {
+	if subSchema.Type == "null" && l.computedNulls[subSchema.Description] != "" {
+		return fmt.Sprintf("null (%s evaluated to None, e.g. because no branch of a conditional rendered a value)",
+			l.computedNulls[subSchema.Description])
+	}
+	if subSchema.Type == "magic" {
+		return "computed " + extractMagicTypeFromSchema(subSchema).Describe()
+	}
+	return subSchema.Type
+}
+
+func isNull(subSchema *v1.JSONSchemaProps) bool {
+	return subSchema.Type == "null"
+}
@@ -0,0 +1,40 @@
+package yttlint
+
+import (
+	"testing"
+)
+
+func TestNullable(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "nullable",
+		schema: `{"type": "object", "required": ["selector", "image"], "properties": {
+			"annotations": {"type": "object", "nullable": true},
+			"containers": {"type": "array"},
+			"selector": {},
+			"image": {"type": "string"},
+			"ports": {"type": "string"}
+		}}`,
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"#@ def ports():\n" +
+			"#@   if data.values.port == 80:\n" +
+			"#@     return \"http\"\n" +
+			"#@   end\n" +
+			"#@ end\n" +
+			"annotations: ~\n" +
+			"containers: ~\n" +
+			"selector: null\n" +
+			"image: ''\n" +
+			"ports: #@ ports()\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".containers expected array got: null",
+			Pos: "test:8",
+		}, {
+			Msg: ".selector required entry must not be null",
+			Pos: "test:9",
+		}, {
+			Msg: ".ports expected string got: null (ports() evaluated to None, e.g. because no branch of a conditional rendered a value) (when the condition at test:3 is false)",
+			Pos: "test:11",
+		}},
+		pedanticErrors: []LinterError{},
+	}})
+}
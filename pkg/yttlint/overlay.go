@@ -0,0 +1,172 @@
+package yttlint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/structmeta"
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+	"go.starlark.net/starlark"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+var overlayAnnotations = []structmeta.AnnotationName{
+	yttoverlay.AnnotationMerge,
+	yttoverlay.AnnotationRemove,
+	yttoverlay.AnnotationReplace,
+	yttoverlay.AnnotationInsert,
+	yttoverlay.AnnotationAppend,
+	yttoverlay.AnnotationAssert,
+	yttoverlay.AnnotationMatch,
+	yttoverlay.AnnotationMatchChildDefaults,
+}
+
+// validateOverlayAnnotations reports annotations of the overlay namespace, which ytt does not know and therefore
+// silently ignores (e.g. @overlay/replac)
+func validateOverlayAnnotations(node yamlmeta.Node) []LinterError {
+	errors := []LinterError{}
+
+	candidates := []string{}
+	for _, name := range overlayAnnotations {
+		candidates = append(candidates, string(name))
+	}
+
+	names := []string{}
+	for name := range template.NewAnnotations(node) {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, string(yttoverlay.AnnotationNs)+"/") || isOverlayAnnotation(name) {
+			continue
+		}
+		message := fmt.Sprintf("unknown annotation @%s", name)
+		if alternatives := suggestAlternatives(name, candidates); len(alternatives) != 0 {
+			message = fmt.Sprintf("%s. Did you mean: @%s?", message, alternatives[0])
+		}
+		errors = append(errors, LinterError{
+			Msg: message,
+			Pos: node.GetPosition().AsCompactString(),
+		})
+	}
+
+	for _, child := range node.GetValues() {
+		if childNode, ok := child.(yamlmeta.Node); ok {
+			errors = append(errors, validateOverlayAnnotations(childNode)...)
+		}
+	}
+	return errors
+}
+
+func isOverlayAnnotation(name string) bool {
+	for _, known := range overlayAnnotations {
+		if string(known) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOverlay returns the documents an overlay document applies to. Unlike ytt, which silently skips overlays
+// whose `when` is not met, matching no document at all is reported for them, as it usually is caused by a typo in the
+// matcher. Overlays which explicitly allow to match nothing (e.g. expects="0+" or missing_ok=True) are not reported.
+func matchOverlay(overlay *yamlmeta.Document, docSets []*yamlmeta.DocumentSet, thread *starlark.Thread) ([]*yamlmeta.Document, []LinterError) {
+	ann, err := yttoverlay.NewDocumentMatchAnnotation(overlay, yttoverlay.MatchChildDefaultsAnnotation{}, false, thread)
+	if err != nil {
+		return nil, []LinterError{{Msg: err.Error(), Pos: overlay.Position.AsCompactString()}}
+	}
+
+	idxs, err := ann.IndexTuples(docSets)
+	if err != nil {
+		_, numMatchErr := err.(yttoverlay.MatchAnnotationNumMatchError)
+		if !numMatchErr || !hasKwarg(overlay, yttoverlay.MatchAnnotationKwargWhen) {
+			return nil, []LinterError{{Msg: err.Error(), Pos: overlay.Position.AsCompactString()}}
+		}
+		if len(idxs) == 0 {
+			return nil, []LinterError{{
+				Msg: "overlay does not match any document of the project",
+				Pos: overlay.Position.AsCompactString(),
+			}}
+		}
+	}
+
+	matched := []*yamlmeta.Document{}
+	for _, idx := range idxs {
+		matched = append(matched, docSets[idx[0]].Items[idx[1]])
+	}
+	return matched, nil
+}
+
+func hasKwarg(overlay *yamlmeta.Document, name string) bool {
+	for _, kwarg := range template.NewAnnotations(overlay).Kwargs(yttoverlay.AnnotationMatch) {
+		if string(kwarg[0].(starlark.String)) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOverlayFields reports fields of an overlay, which do not exist in the schemas of the matched documents.
+// The errors equal the ones of the validation of the overlaid documents, so fields added by the overlay are
+// not reported twice.
+func validateOverlayFields(overlay *yamlmeta.Document, matched []*yamlmeta.Document) []LinterError {
+	errors := []LinterError{}
+
+	seen := map[kubernetesGVK]bool{}
+	for _, doc := range matched {
+		gvk, _ := extractKind(doc)
+		if gvk.kind == "" || seen[gvk] {
+			continue
+		}
+		seen[gvk] = true
+
+		schema, err := loadK8SSchema(gvk)
+		if err != nil {
+			// already reported by the validation of the document
+			continue
+		}
+		errors = append(errors, validateFields(schema.Definitions, overlay.Value, schema, "")...)
+	}
+	return errors
+}
+
+func validateFields(defs v1.JSONSchemaDefinitions, value interface{}, schema *v1.JSONSchemaProps, path string) []LinterError {
+	errors := []LinterError{}
+
+	if schema.Ref != nil && strings.HasPrefix(*schema.Ref, "#/definitions/") {
+		deref := defs[(*schema.Ref)[14:]]
+		return validateFields(defs, value, &deref, path)
+	}
+
+	switch typedValue := value.(type) {
+	case *yamlmeta.Map:
+		closed := len(schema.Properties) > 0 && len(schema.PatternProperties) == 0 &&
+			schema.AdditionalProperties == nil && !isPreservingUnknownFields(schema)
+		// converted just like the overlaid document, so the errors point to the same location
+		object := convert(typedValue)
+		for _, item := range typedValue.Items {
+			key := fmt.Sprint(item.Key)
+			prop, ok := schema.Properties[key]
+			if !ok {
+				if closed {
+					val := object.Properties[key]
+					errors = append(errors, generateAdditionalPropertiesError(&val, path, key, schema.Properties))
+				}
+				continue
+			}
+			errors = append(errors, validateFields(defs, item.Value, &prop, fmt.Sprintf("%s.%s", path, key))...)
+		}
+	case *yamlmeta.Array:
+		if schema.Items == nil || schema.Items.Schema == nil {
+			break
+		}
+		for _, item := range typedValue.Items {
+			errors = append(errors, validateFields(defs, item.Value, schema.Items.Schema, path+"[]")...)
+		}
+	}
+	return errors
+}
@@ -0,0 +1,124 @@
+package yttlint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Profile is a named set of data values, e.g. the data values of an environment
+type Profile struct {
+	Name       string
+	DataValues *DataValues
+}
+
+// LoadProfiles loads the given profiles, each profile is either the name of a file in .ytt-lint/profiles or a path
+// to a data values file. If names is empty, every profile in .ytt-lint/profiles is loaded. The data values of a
+// profile are overlaid on top of the data values files given by opts, but below the key-value pairs and
+// environment variables.
+func LoadProfiles(root string, names []string, opts DataValuesOpts) ([]Profile, error) {
+	profilesDir := filepath.Join(root, ".ytt-lint", "profiles")
+
+	files := []string{}
+	if len(names) == 0 && root != "" {
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(profilesDir, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+	for _, name := range names {
+		file := name
+		if root != "" {
+			profileFile, err := findProfile(profilesDir, name)
+			if err != nil {
+				return nil, err
+			}
+			if profileFile != "" {
+				file = profileFile
+			}
+		}
+		files = append(files, file)
+	}
+
+	profiles := []Profile{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		profileOpts := opts
+		if dataValuesAnnotationRegexp.Match(data) {
+			profileOpts.Files = append(append([]string{}, opts.Files...), file)
+		} else {
+			profileOpts.PlainFiles = append(append([]string{}, opts.PlainFiles...), file)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		dataValues, err := LoadDataValues(profileOpts)
+		if err != nil {
+			return nil, fmt.Errorf("Loading profile '%s': %v", name, err)
+		}
+		profiles = append(profiles, Profile{Name: name, DataValues: dataValues})
+	}
+
+	return profiles, nil
+}
+
+// findProfile returns the file of the profile name in .ytt-lint/profiles, which either ends with .yaml or .yml. If
+// there is no such profile, an empty string is returned.
+func findProfile(profilesDir, name string) (string, error) {
+	found := []string{}
+	for _, ext := range []string{".yaml", ".yml"} {
+		file := filepath.Join(profilesDir, name+ext)
+		if _, err := os.Stat(file); err == nil {
+			found = append(found, file)
+		}
+	}
+	if len(found) > 1 {
+		return "", fmt.Errorf("Profile '%s' is ambiguous, found %s", name, strings.Join(found, " and "))
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+	return found[0], nil
+}
+
+// forEachProfile calls lint once per profile with the data values of the profile. Identical errors of different
+// profiles are merged. Without profiles lint is called once.
+func (l *Linter) forEachProfile(lint func() []LinterError) []LinterError {
+	if len(l.Profiles) == 0 {
+		return lint()
+	}
+
+	defer func(dataValues *DataValues) {
+		l.DataValues = dataValues
+	}(l.DataValues)
+
+	errors := []LinterError{}
+	index := map[string]int{}
+	for _, profile := range l.Profiles {
+		l.DataValues = profile.DataValues
+		for _, lintError := range lint() {
+			key := fmt.Sprintf("%s|%s|%s", lintError.Pos, lintError.Code, lintError.Msg)
+			if i, seen := index[key]; seen {
+				profiles := errors[i].Profiles
+				if profiles[len(profiles)-1] != profile.Name {
+					errors[i].Profiles = append(profiles, profile.Name)
+				}
+				continue
+			}
+			lintError.Profiles = []string{profile.Name}
+			index[key] = len(errors)
+			errors = append(errors, lintError)
+		}
+	}
+	return errors
+}
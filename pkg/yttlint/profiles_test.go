@@ -0,0 +1,82 @@
+package yttlint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestProfiles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	root := "../../examples/profiles"
+	files, err := FindDataValuesFiles(root, func(path string) bool { return false })
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(Equal([]string{root + "/values.yaml"}))
+
+	profiles, err := LoadProfiles(root, nil, DataValuesOpts{Files: files})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(profiles).To(HaveLen(2))
+	g.Expect(profiles[0].Name).To(Equal("dev"))
+	g.Expect(profiles[1].Name).To(Equal("prod"))
+
+	linter := &Linter{Profiles: profiles}
+	errors := linter.Lint("#@ load(\"@ytt:data\", \"data\")\n"+
+		"apiVersion: v1\n"+
+		"kind: Service\n"+
+		"metadata:\n"+
+		"  name: #@ data.values.name\n"+
+		"  namespace: 1\n"+
+		"spec:\n"+
+		"  ports:\n"+
+		"  - port: #@ data.values.replicas\n", "test", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg:      ".metadata.namespace expected string got: integer",
+		Pos:      "test:6",
+		Profiles: []string{"dev", "prod"},
+	}, {
+		Msg:      ".spec.ports[0].port expected integer got: string",
+		Pos:      "test:9",
+		Profiles: []string{"dev"},
+	}, {
+		Msg:      ".metadata.name expected string got: integer",
+		Pos:      "test:5",
+		Profiles: []string{"prod"},
+	}}))
+
+	profiles, err = LoadProfiles(root, []string{"prod", root + "/values.yaml"}, DataValuesOpts{Files: files})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(profiles).To(HaveLen(2))
+	g.Expect(profiles[0].Name).To(Equal("prod"))
+	g.Expect(profiles[1].Name).To(Equal("values"))
+
+	_, err = LoadProfiles(root, []string{"staging"}, DataValuesOpts{Files: files})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestProfileExtensions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	root, err := ioutil.TempDir("", "ytt-lint-profiles")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(root)
+
+	profilesDir := filepath.Join(root, ".ytt-lint", "profiles")
+	g.Expect(os.MkdirAll(profilesDir, os.ModePerm)).To(Succeed())
+	for _, file := range []string{"dev.yml", "prod.yaml", "prod.yml"} {
+		g.Expect(ioutil.WriteFile(filepath.Join(profilesDir, file), []byte("name: "+file+"\n"), os.ModePerm)).To(Succeed())
+	}
+
+	profiles, err := LoadProfiles(root, []string{"dev"}, DataValuesOpts{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(profiles).To(HaveLen(1))
+	g.Expect(profiles[0].Name).To(Equal("dev"))
+
+	_, err = LoadProfiles(root, []string{"prod"}, DataValuesOpts{})
+	g.Expect(err).To(MatchError(fmt.Sprintf("Profile 'prod' is ambiguous, found %s and %s",
+		filepath.Join(profilesDir, "prod.yaml"), filepath.Join(profilesDir, "prod.yml"))))
+}
@@ -0,0 +1,154 @@
+package yttlint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/template"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	"github.com/k14s/ytt/pkg/yttlibrary"
+	yttoverlay "github.com/k14s/ytt/pkg/yttlibrary/overlay"
+	"go.starlark.net/starlark"
+)
+
+// LintProject renders all given templates, applies the overlays among them in the given order (just like ytt does
+// for the files passed via -f) and validates the resulting documents. Errors point to the line of the template or
+// overlay which produced the offending node.
+func (l *Linter) LintProject(filenames []string, autoImport bool) (errors []LinterError) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "Recovered '%v' while linting project\n", r)
+			errors = []LinterError{{
+				Msg: fmt.Sprintf("could not lint because of an internal error: %v", r),
+				Pos: fmt.Sprintf("%s:1", filenames[0]),
+			}}
+		}
+	}()
+	errors = l.forEachProfile(func() []LinterError {
+		return l.lintProject(filenames, autoImport)
+	})
+	for _, filename := range filenames {
+		// read errors are reported by lintProject already
+		if data, err := ioutil.ReadFile(filename); err == nil && !helmChartRegex.Match(data) {
+			errors = append(errors, analyse(string(data), filename)...)
+		}
+	}
+	errors = l.withoutDisabled(errors)
+	return
+}
+
+func (l *Linter) lintProject(filenames []string, autoImport bool) []LinterError {
+	errors := []LinterError{}
+	docSets := []*yamlmeta.DocumentSet{}
+	docSetFiles := map[*yamlmeta.DocumentSet]string{}
+	overlays := []*yamlmeta.Document{}
+
+	for _, filename := range filenames {
+		errors = append(errors, l.dataValuesErrors(filename)...)
+		if isLibraryFile(filename) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			errors = append(errors, LinterError{
+				Msg: err.Error(),
+				Pos: fmt.Sprintf("%s:1", filename),
+			})
+			continue
+		}
+		if helmChartRegex.Match(data) {
+			continue
+		}
+
+		docSet, renderErrors := l.render(string(data), filename)
+		errors = append(errors, renderErrors...)
+		if docSet == nil {
+			continue
+		}
+
+		items := []*yamlmeta.Document{}
+		for _, doc := range docSet.Items {
+			anns := template.NewAnnotations(doc)
+			switch {
+			case anns.Has(yttoverlay.AnnotationMatch):
+				overlays = append(overlays, doc)
+			case anns.Has(yttlibrary.AnnotationDataValues) || anns.Has(annotationDataValuesSchema) || doc.IsEmpty():
+				// data values are no output of ytt
+			default:
+				items = append(items, doc)
+			}
+		}
+		if len(items) > 0 {
+			docSet.Items = items
+			docSets = append(docSets, docSet)
+			docSetFiles[docSet] = filename
+		}
+	}
+
+	thread := &starlark.Thread{Name: "overlay-post-processing"}
+	for _, overlay := range overlays {
+		errors = append(errors, validateOverlayAnnotations(overlay)...)
+		matched, matchErrors := matchOverlay(overlay, docSets, thread)
+		if len(matchErrors) > 0 {
+			errors = append(errors, matchErrors...)
+			continue
+		}
+		errors = append(errors, validateOverlayFields(overlay, matched)...)
+
+		op := yttoverlay.OverlayOp{
+			// a list of document sets keeps the association of documents to files
+			Left: docSets,
+			Right: &yamlmeta.DocumentSet{
+				Items: []*yamlmeta.Document{overlay},
+			},
+			Thread: thread,
+		}
+		result, err := op.Apply()
+		if err != nil {
+			errors = append(errors, LinterError{
+				Msg: err.Error(),
+				Pos: overlay.Position.AsCompactString(),
+			})
+			continue
+		}
+		docSets = result.([]*yamlmeta.DocumentSet)
+	}
+
+	for _, docSet := range docSets {
+		errors = append(errors, l.validateDocumentSet(docSet, docSetFiles[docSet], autoImport)...)
+	}
+
+	// fields added by an overlay are reported by the overlay checks and the validation of the result
+	return uniqueErrors(errors)
+}
+
+func uniqueErrors(errors []LinterError) []LinterError {
+	result := []LinterError{}
+	seen := map[string]bool{}
+	for _, lintError := range errors {
+		key := fmt.Sprintf("%s|%s|%s", lintError.Pos, lintError.Code, lintError.Msg)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, lintError)
+	}
+	return result
+}
+
+// render evaluates a template as is, without exploring its branches
+func (l *Linter) render(data, filename string) (*yamlmeta.DocumentSet, []LinterError) {
+	compiledTemplate, _, syntaxErrors := compile(data, filename)
+	if compiledTemplate == nil {
+		return nil, syntaxErrors
+	}
+	return l.evaluate(compiledTemplate, filename, nil)
+}
+
+// isLibraryFile reports whether ytt treats a file as a library, which is only evaluated when loaded
+func isLibraryFile(filename string) bool {
+	return strings.HasSuffix(filename, ".lib.yml") || strings.HasSuffix(filename, ".lib.yaml")
+}
@@ -0,0 +1,51 @@
+package yttlint
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLintProject(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	root := "../../examples/project"
+	files, err := FindDataValuesFiles(root, func(path string) bool { return false })
+	g.Expect(err).NotTo(HaveOccurred())
+	dataValues, err := LoadDataValues(DataValuesOpts{Files: files})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the overlays allowing to match nothing (expects="0+" and missing_ok=True) are not reported
+	linter := &Linter{DataValues: dataValues, Root: root}
+	errors := linter.LintProject([]string{root + "/configmap.yaml", root + "/overlay.yaml", root + "/values.yaml"}, false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: ".data.debug expected string got: boolean",
+		Pos: root + "/overlay.yaml:7",
+	}, {
+		Msg: "Expected number of matched nodes to be 1, but was 0",
+		Pos: root + "/overlay.yaml:9",
+	}, {
+		Msg: ".metadata.lables additional properties are not permitted. Did you mean: labels?",
+		Pos: root + "/overlay.yaml:16",
+	}, {
+		Msg: "unknown annotation @overlay/replac. Did you mean: @overlay/replace?",
+		Pos: root + "/overlay.yaml:19",
+	}, {
+		Msg: "overlay does not match any document of the project",
+		Pos: root + "/overlay.yaml:21",
+	}}))
+
+	// linted in isolation the template is invalid, the overlay fixes it
+	errors = linter.Lint("apiVersion: v1\nkind: ConfigMap\ndata:\n  replicas: 1\n", "test", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: ".data.replicas expected string got: integer",
+		Pos: "test:4",
+	}}))
+
+	// unknown overlay annotations are found without the project as well
+	errors = linter.Lint("#@overlay/replac\nkey: value\n", "test", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "unknown annotation @overlay/replac. Did you mean: @overlay/replace?",
+		Pos: "test:2",
+	}}))
+}
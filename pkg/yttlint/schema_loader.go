@@ -19,6 +19,15 @@ type kubernetesGVK struct {
 
 var schemaCache map[string]*v1.JSONSchemaProps
 
+// parseAPIVersion splits an apiVersion into group and version, resources without a group belong to "core"
+func parseAPIVersion(apiVersion string) (string, string) {
+	gv := strings.SplitN(apiVersion, "/", 2)
+	if len(gv) == 1 {
+		return "core", gv[0]
+	}
+	return gv[0], gv[1]
+}
+
 func loadK8SSchema(gvk kubernetesGVK) (*v1.JSONSchemaProps, error) {
 	gvk.kind = strings.ToLower(gvk.kind)
 	key := path.Join("k8s", gvk.group, gvk.version, gvk.kind)
@@ -0,0 +1,101 @@
+package yttlint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/k14s/ytt/pkg/filepos"
+	"github.com/k14s/ytt/pkg/template"
+	"go.starlark.net/starlark"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+// isStarlarkFile reports whether a file is a starlark module (e.g. a helper loaded by templates)
+func isStarlarkFile(filename string) bool {
+	return strings.HasSuffix(filename, ".star")
+}
+
+// lintStarlark evaluates a starlark module and calls every exported function with computed values as arguments, so
+// errors inside of functions are found even if no template calls them. Private functions are linted through the
+// functions calling them.
+func (l *Linter) lintStarlark(data, filename string) []LinterError {
+	result, errors := l.execute(compileStarlark(data, filename), filename, nil)
+	if result == nil {
+		return errors
+	}
+
+	names := []string{}
+	for name := range result.globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errors = []LinterError{}
+	for _, name := range names {
+		function, ok := result.globals[name].(*starlark.Function)
+		if !ok || function.Position().Filename() != filename {
+			// loaded functions are linted in their own module
+			continue
+		}
+		if strings.HasPrefix(name, "_") {
+			// private functions are only called by the module itself, which passes the actual arguments
+			continue
+		}
+
+		_, err := starlark.Call(result.thread, function, nil, computedArgs(function))
+		if err == nil {
+			continue
+		}
+		if evalErr, ok := err.(*starlark.EvalError); ok && !isCausedByComputedArgs(evalErr) {
+			// the error is reported once, at the line of the called function leading to it (e.g. the call of another
+			// function with the wrong number of arguments), as errors inside of other functions are found when
+			// calling them
+			multiErr := template.NewCompiledTemplateMultiError(evalErr, result.loader)
+			if callErrors := mapEvalError(multiErr, filename); len(callErrors) > 0 {
+				errors = append(errors, callErrors[len(callErrors)-1])
+			}
+		}
+	}
+
+	return append(errors, result.usage.errors...)
+}
+
+// compileStarlark compiles a starlark module just like ytt does for modules loaded by templates, but with the builtins
+// of the linter. They are loaded in an additional first line, as the first line of the module might be a compound
+// statement.
+func compileStarlark(data, filename string) *template.CompiledTemplate {
+	instructions := template.NewInstructionSet()
+	pos := filepos.NewPosition(1)
+	pos.SetFile(filename)
+	code := append([]template.TemplateLine{{Instruction: instructions.NewCode("")}},
+		template.NewCodeFromBytesAtPosition([]byte(data), pos, instructions)...)
+	compiledTemplate := template.NewCompiledTemplate(filename, code,
+		instructions, template.NewNodes(), template.EvaluationCtxDialects{})
+	injectLintBuiltins(compiledTemplate)
+	return compiledTemplate
+}
+
+// computedArgs passes a computed value for every named parameter of a function
+func computedArgs(function *starlark.Function) []starlark.Tuple {
+	n := function.NumParams()
+	if function.HasVarargs() {
+		n--
+	}
+	if function.HasKwargs() {
+		n--
+	}
+
+	kwargs := []starlark.Tuple{}
+	for i := 0; i < n; i++ {
+		name, _ := function.Param(i)
+		kwargs = append(kwargs, starlark.Tuple{starlark.String(name), magic.Any()})
+	}
+	return kwargs
+}
+
+// isCausedByComputedArgs reports errors which might not occur with the actual arguments, i.e. calls of fail(), which
+// are usually guarded by checks of the arguments
+func isCausedByComputedArgs(err *starlark.EvalError) bool {
+	return strings.HasPrefix(err.Msg, "fail: ")
+}
@@ -0,0 +1,44 @@
+package yttlint
+
+import (
+	"io/ioutil"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLintStarlark(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	data, err := ioutil.ReadFile("../../examples/lint/helpers.star")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	linter := &Linter{}
+	errors := linter.Lint(string(data), "test.star", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "dict has no .app field or method",
+		Pos: "test.star:12",
+	}, {
+		Msg: "function name accepts 1 positional argument (2 given)",
+		Pos: "test.star:16",
+	}}))
+
+	// private functions are linted through the functions calling them
+	errors = linter.Lint("def _f(x):\n  return len(x) + \"-\"\nend\ndef g():\n  return _f([])\nend\n", "test.star", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "unknown binary op: int + string",
+		Pos: "test.star:5",
+	}}))
+
+	errors = linter.Lint("def f(x):\n  return x +\nend\n", "test.star", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "got newline, want primary expression",
+		Pos: "test.star:3",
+	}}))
+
+	errors = linter.Lint("def f(x):\n  return y\nend\n", "test.star", false)
+	g.Expect(errors).To(ConsistOf([]LinterError{{
+		Msg: "undefined: y",
+		Pos: "test.star:2",
+	}}))
+}
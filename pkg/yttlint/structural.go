@@ -0,0 +1,142 @@
+package yttlint
+
+import (
+	"encoding/json"
+	"strings"
+
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/SAP/ytt-lint/pkg/magic"
+)
+
+func isPreservingUnknownFields(schema *v1.JSONSchemaProps) bool {
+	return schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields
+}
+
+func (l *Linter) validateIntOrString(subSchema *v1.JSONSchemaProps, path string) []LinterError {
+	errors := make([]LinterError, 0)
+
+	switch subSchema.Type {
+	case "integer", "string":
+	case "magic":
+		computed := extractMagicTypeFromSchema(subSchema)
+		if !computed.CouldBe(magic.MagicType{CouldBeString: true, CouldBeInt: true}) {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected int-or-string got: %s", path, l.describeType(subSchema)))
+		} else if l.Pedantic && !computed.CouldOnlyBe(magic.MagicType{CouldBeString: true, CouldBeInt: true}) {
+			errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected int-or-string got a computed value. Tip: use str(...) or int(...) to convert to int or string`, path))
+		}
+	default:
+		errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected int-or-string got: %s", path, l.describeType(subSchema)))
+	}
+
+	return errors
+}
+
+// validateEmbeddedResource validates an embedded object against the schema of its own apiVersion and kind
+func (l *Linter) validateEmbeddedResource(subSchema *v1.JSONSchemaProps, path string) []LinterError {
+	errors := make([]LinterError, 0)
+
+	if subSchema.Type != "object" {
+		return errors
+	}
+
+	var gvk kubernetesGVK
+	for _, key := range []string{"apiVersion", "kind"} {
+		prop, ok := subSchema.Properties[key]
+		if !ok {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s missing required entry %s", path, key))
+			continue
+		}
+		if prop.Type != "string" || prop.Default == nil {
+			// computed or invalid, the latter is reported by the regular validation
+			return errors
+		}
+		var value string
+		err := json.Unmarshal(prop.Default.Raw, &value)
+		if err != nil {
+			panic(err)
+		}
+		if key == "kind" {
+			gvk.kind = value
+		} else {
+			gvk.group, gvk.version = parseAPIVersion(value)
+		}
+	}
+	if len(errors) > 0 {
+		return errors
+	}
+
+	schema, err := loadK8SSchema(gvk)
+	if err != nil {
+		kind := subSchema.Properties["kind"]
+		return append(errors, appendLocationIfKnownf(&kind, "%s Error loading schema for kind %s: %v", path, gvk.kind, err.Error()))
+	}
+
+	return append(errors, l.isSubset(schema.Definitions, subSchema, schema, path)...)
+}
+
+// validateListType reports duplicate entries in lists with x-kubernetes-list-type map or set
+func (l *Linter) validateListType(subSchema, schema *v1.JSONSchemaProps, path string) []LinterError {
+	errors := make([]LinterError, 0)
+
+	if subSchema.Type != "array" || subSchema.Items == nil || schema.XListType == nil {
+		return errors
+	}
+
+	var keyOf func(item *v1.JSONSchemaProps) (string, bool)
+	switch *schema.XListType {
+	case "map":
+		if len(schema.XListMapKeys) == 0 {
+			return errors
+		}
+		keyOf = func(item *v1.JSONSchemaProps) (string, bool) {
+			return listMapKey(item, schema.XListMapKeys)
+		}
+	case "set":
+		keyOf = func(item *v1.JSONSchemaProps) (string, bool) {
+			if !isLiteral(item) {
+				return "", false
+			}
+			return string(item.Default.Raw), true
+		}
+	default:
+		return errors
+	}
+
+	items := subSchema.Items.JSONSchemas
+	seen := map[string]int{}
+	for i := range items {
+		key, ok := keyOf(&items[i])
+		if !ok {
+			continue
+		}
+		first, ok := seen[key]
+		if !ok {
+			seen[key] = i
+			continue
+		}
+		errors = append(errors,
+			appendLocationIfKnownf(&items[first], "%s[%d] duplicate entry %s, also defined by %s[%d] at %s", path, first, key, path, i, items[i].Description),
+			appendLocationIfKnownf(&items[i], "%s[%d] duplicate entry %s, first defined by %s[%d] at %s", path, i, key, path, first, items[first].Description),
+		)
+	}
+
+	return errors
+}
+
+// listMapKey joins the values of all map keys of an item, items where a key is missing or computed are ignored
+func listMapKey(item *v1.JSONSchemaProps, keys []string) (string, bool) {
+	if item.Type != "object" {
+		return "", false
+	}
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prop, ok := item.Properties[key]
+		if !ok || !isLiteral(&prop) {
+			return "", false
+		}
+		parts = append(parts, key+"="+string(prop.Default.Raw))
+	}
+	return strings.Join(parts, ","), true
+}
@@ -0,0 +1,87 @@
+package yttlint
+
+import (
+	"testing"
+)
+
+func TestStructuralSchemaExtensions(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "structural schema extensions",
+		schema: `{"type": "object", "properties": {
+			"config": {"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+			"port": {"x-kubernetes-int-or-string": true, "anyOf": [{"type": "integer"}, {"type": "string"}]},
+			"template": {"type": "object", "x-kubernetes-embedded-resource": true, "x-kubernetes-preserve-unknown-fields": true}
+		}}`,
+		schemas: map[string]string{
+			"k8s/core/v1/configmap": `{"$ref": "#/definitions/configmap", "definitions": {"configmap": {"type": "object", "properties": {
+				"apiVersion": {"type": "string"},
+				"kind": {"type": "string"},
+				"metadata": {"type": "object", "properties": {"name": {"type": "string"}}},
+				"data": {"type": "object", "additionalProperties": {"type": "string"}}
+			}}}}`,
+		},
+		document: "config:\n" +
+			"  anything:\n" +
+			"    goes: here\n" +
+			"port: true\n" +
+			"template:\n" +
+			"  apiVersion: v1\n" +
+			"  kind: ConfigMap\n" +
+			"  metadata:\n" +
+			"    name: test\n" +
+			"  data:\n" +
+			"    key: 1\n" +
+			"---\n" +
+			"port: http\n" +
+			"template:\n" +
+			"  kind: ConfigMap\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".port expected int-or-string got: boolean",
+			Pos: "test:4",
+		}, {
+			Msg: ".template.data.key expected string got: integer",
+			Pos: "test:11",
+		}, {
+			Msg: ".template missing required entry apiVersion",
+			Pos: "test:14",
+		}},
+		pedanticErrors: []LinterError{},
+	}})
+}
+
+func TestListTypes(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "list types",
+		schema: `{"type": "object", "properties": {
+			"ports": {"type": "array", "x-kubernetes-list-type": "map", "x-kubernetes-list-map-keys": ["port", "protocol"], "items": {
+				"type": "object", "properties": {"port": {"type": "integer"}, "protocol": {"type": "string"}}
+			}},
+			"finalizers": {"type": "array", "x-kubernetes-list-type": "set", "items": {"type": "string"}}
+		}}`,
+		document: "ports:\n" +
+			"- port: 80\n" +
+			"  protocol: TCP\n" +
+			"- port: 80\n" +
+			"  protocol: UDP\n" +
+			"- port: 80\n" +
+			"  protocol: TCP\n" +
+			"finalizers:\n" +
+			"- a\n" +
+			"- b\n" +
+			"- a\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: `.ports[0] duplicate entry port=80,protocol="TCP", also defined by .ports[2] at test:6`,
+			Pos: "test:2",
+		}, {
+			Msg: `.ports[2] duplicate entry port=80,protocol="TCP", first defined by .ports[0] at test:2`,
+			Pos: "test:6",
+		}, {
+			Msg: `.finalizers[0] duplicate entry "a", also defined by .finalizers[2] at test:11`,
+			Pos: "test:9",
+		}, {
+			Msg: `.finalizers[2] duplicate entry "a", first defined by .finalizers[0] at test:9`,
+			Pos: "test:11",
+		}},
+		pedanticErrors: []LinterError{},
+	}})
+}
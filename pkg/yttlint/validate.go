@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
@@ -15,6 +14,7 @@ import (
 	"github.com/adrg/strutil/metrics"
 	"github.com/k14s/ytt/pkg/cmd/core"
 	"github.com/k14s/ytt/pkg/files"
+	"github.com/k14s/ytt/pkg/orderedmap"
 	"github.com/k14s/ytt/pkg/template"
 	tplcore "github.com/k14s/ytt/pkg/template/core"
 	"github.com/k14s/ytt/pkg/workspace"
@@ -33,6 +33,9 @@ type myTemplateLoader struct {
 	compiledTemplate *template.CompiledTemplate
 	name             string
 	api              yttlibrary.API
+	dataValues       starlark.Value
+	files            dataFiles
+	modules          modules
 }
 
 var _ template.CompiledTemplateLoader = myTemplateLoader{}
@@ -47,27 +50,44 @@ func (l myTemplateLoader) FindCompiledTemplate(module string) (*template.Compile
 func (l myTemplateLoader) Load(
 	thread *starlark.Thread, module string) (starlark.StringDict, error) {
 
+	if module == lintBuiltinsModule {
+		return lintBuiltinsMembers(), nil
+	}
 	if strings.HasPrefix(module, "@ytt:") {
 		if module == "@ytt:data" {
+			members := orderedmap.NewMap()
+			if l.dataValues == nil {
+				members.Set("values", magic.Any())
+			} else {
+				members.Set("values", l.dataValues)
+			}
+			for name, member := range l.files.members() {
+				members.Set(name, member)
+			}
 			return starlark.StringDict{
-				"data": &magic.MagicType{},
+				"data": magic.NewStruct(members),
 			}, nil
 		}
 		res, err := l.api.FindModule(module[5:])
 		if err == nil {
 			return res, nil
 		}
+		return l.TemplateLoader.Load(thread, module)
 	}
 
+	module, err := l.modules.resolve(module)
+	if err != nil {
+		return nil, err
+	}
 	return l.TemplateLoader.Load(thread, module)
 }
 
-func (l myTemplateLoader) FilePaths(string) ([]string, error) {
-	return nil, fmt.Errorf("unexpected call to FilePaths") // this should be handled by the injected magic-data-type
+func (l myTemplateLoader) FilePaths(path string) ([]string, error) {
+	return l.files.FilePaths(path)
 }
 
-func (l myTemplateLoader) FileData(string) ([]byte, error) {
-	return nil, fmt.Errorf("unexpected call to FileData") // this should be handled by the injected magic-data-type
+func (l myTemplateLoader) FileData(path string) ([]byte, error) {
+	return l.files.FileData(path)
 }
 
 func (l myTemplateLoader) LoadData(
@@ -84,90 +104,29 @@ func (l myTemplateLoader) ListData(
 	return nil, fmt.Errorf("listData is not supported")
 }
 
-func isIf(meta *yamlmeta.Meta) bool {
-	// TODO: ignore whitespace
-	return strings.HasPrefix(meta.Data, "@ if ")
-}
-
-func isElse(meta *yamlmeta.Meta) bool {
-	// TODO: ignore whitespace
-	return strings.HasPrefix(meta.Data, "@ else:")
-}
-
-func isEnd(meta *yamlmeta.Meta) bool {
-	// TODO: ignore whitespace
-	return strings.HasPrefix(meta.Data, "@ end")
-}
-
-func injectIfHandling(val interface{}) {
-	if val == nil {
-		return
-	}
-
-	switch typedVal := val.(type) {
-	case *yamlmeta.DocumentSet:
-		//injectIfHandling(typedVal.Metas)
-		for _, item := range typedVal.Items {
-			injectIfHandling(item)
-		}
-
-	case *yamlmeta.Map:
-
-		prefix := ""
-		for _, item := range typedVal.Items {
-			for _, meta := range item.Metas {
-				if isIf(meta) {
-					prefix = "__ytt_lint_t_"
-
-				}
-				if isElse(meta) {
-					prefix = "__ytt_lint_f_"
-					// FIXME: do proper filtering
-					item.Metas = []*yamlmeta.Meta{}
-				}
-				if isEnd(meta) {
-					prefix = ""
-				}
-			}
-			item.Key = prefix + fmt.Sprint(item.Key)
-		}
-
-		for _, item := range typedVal.Items {
-			injectIfHandling(item)
-		}
-	case *yamlmeta.MapItem:
-		injectIfHandling(typedVal.Key)
-		injectIfHandling(typedVal.Value)
-
-	case *yamlmeta.Array:
-		//injectIfHandling(typedVal.Metas)
-		for _, item := range typedVal.Items {
-			injectIfHandling(item)
-		}
-	case *yamlmeta.ArrayItem:
-		//injectIfHandling(typedVal.Metas)
-		injectIfHandling(typedVal.Value)
-
-	case *yamlmeta.Document:
-		//injectIfHandling(typedVal.Metas)
-		injectIfHandling(typedVal.Value)
-
-	case string:
-	case int:
-	case bool:
-	case float64:
-
-	default:
-		panic(fmt.Sprintf("unsupported type hit injectIfHandling %T", typedVal))
-	}
-}
-
 var (
 	lineErrRegexp = regexp.MustCompile(`^yaml: line (?P<num>\d+): (?P<msg>.+)$`)
 )
 
 type Linter struct {
 	Pedantic bool
+	// DataValues are used for data.values, if nil every data value is a MagicType
+	DataValues *DataValues
+	// Profiles are alternative data values, if set every template is linted once per profile
+	Profiles []Profile
+	// Root is the folder load(), data.read and data.list resolve absolute paths against. Defaults to the closest
+	// folder containing .ytt-lint/ignore or .ytt-lint/profiles or the folder of the linted file.
+	Root string
+	// IsExcluded hides ignored files from load(), data.read and data.list, if set
+	IsExcluded func(path string) bool
+	// Disabled lists the codes of errors, which are not reported (e.g. ErrorCodeUnusedLoad)
+	Disabled []ErrorCode
+
+	usedDataValues map[string]bool
+	// computedNulls are the template expressions of the validated document, which evaluated to None, by position
+	computedNulls map[string]string
+	// libraryFiles caches the files below a root folder
+	libraryFiles map[string][]*files.File
 }
 
 // Lint applies linting to a given ytt template
@@ -181,10 +140,36 @@ func (l *Linter) Lint(data, filename string, autoImport bool) (errors []LinterEr
 			}}
 		}
 	}()
-	errors = l.lint(data, filename, autoImport)
+	errors = l.forEachProfile(func() []LinterError {
+		return append(l.lint(data, filename, autoImport), l.dataValuesErrors(filename)...)
+	})
+	if !helmChartRegex.MatchString(data) {
+		// the code is the same for all profiles
+		errors = append(errors, analyse(data, filename)...)
+	}
+	errors = l.withoutDisabled(errors)
 	return
 }
 
+func (l *Linter) withoutDisabled(errors []LinterError) []LinterError {
+	if len(l.Disabled) == 0 {
+		return errors
+	}
+	result := []LinterError{}
+	for _, lintError := range errors {
+		disabled := false
+		for _, code := range l.Disabled {
+			if lintError.Code == code {
+				disabled = true
+			}
+		}
+		if !disabled {
+			result = append(result, lintError)
+		}
+	}
+	return result
+}
+
 var helmChartRegex = regexp.MustCompile("{{")
 
 func (l *Linter) lint(data, filename string, autoImport bool) []LinterError {
@@ -198,6 +183,109 @@ func (l *Linter) lint(data, filename string, autoImport bool) []LinterError {
 		}}
 	}
 
+	if isStarlarkFile(filename) {
+		return l.lintStarlark(data, filename)
+	}
+
+	return l.lintBranches(data, filename, func(newVal *yamlmeta.DocumentSet) []LinterError {
+		errors := l.validateDocumentSet(newVal, filename, autoImport)
+		return append(errors, validateOverlayAnnotations(newVal)...)
+	})
+}
+
+// validateDocumentSet validates all kubernetes resources and concourse pipelines of a rendered template
+func (l *Linter) validateDocumentSet(newVal *yamlmeta.DocumentSet, filename string, autoImport bool) []LinterError {
+	errors := make([]LinterError, 0)
+
+	if autoImport {
+		err := importCRDs(filename, newVal)
+		if err != nil {
+			panic(fmt.Errorf("autoimport failed: %w", err))
+		}
+	}
+
+	for _, doc := range newVal.Items {
+		gvk, item := extractKind(doc)
+		var err error
+		var schema *v1.JSONSchemaProps
+		if gvk.kind != "" {
+			schema, err = loadK8SSchema(gvk)
+			if err != nil {
+				errors = append(errors,
+					appendLocationIfKnownf(item, "Error loading schema for kind %s: %v\n", gvk.kind, err.Error()))
+				continue
+			}
+		} else if isConcoursePipeline(doc) {
+			schema, err = loadConcourseSchema()
+			if err != nil {
+				panic(err)
+			}
+		} else {
+			continue
+			// TODO: print warning if not a trivial document
+		}
+
+		errors = append(errors, l.validateDocument(doc, filename, schema)...)
+	}
+
+	return errors
+}
+
+// validateDocument validates a rendered document against schema and evaluates its validation rules
+func (l *Linter) validateDocument(doc *yamlmeta.Document, filename string, schema *v1.JSONSchemaProps) []LinterError {
+	subSchema := convert(doc.Value)
+	if subSchema.Description == "" && doc.Position.IsKnown() {
+		doc.Position.SetFile(filename)
+		subSchema.Description = doc.Position.AsString()
+	}
+
+	l.computedNulls = computedNulls(doc.Value)
+	errors := l.isSubset(schema.Definitions, subSchema, schema, "")
+	errors = append(errors, l.validateRules(doc, schema)...)
+	return errors
+}
+
+// lintBranches evaluates the template once as is and once per branch of every conditional and validates every
+// rendering. Errors which only occur in a certain branch are attributed to it.
+func (l *Linter) lintBranches(data, filename string, validate func(*yamlmeta.DocumentSet) []LinterError) []LinterError {
+	compiledTemplate, conditionals, syntaxErrors := compile(data, filename)
+	if compiledTemplate == nil {
+		return syntaxErrors
+	}
+
+	unforced := newUnforcedScenario()
+	newVal, errors := l.evaluate(compiledTemplate, filename, unforced)
+	if newVal != nil {
+		errors = append(errors, validate(newVal)...)
+	}
+	errors = unforced.withoutExpectedFailures(errors, conditionals)
+
+	seen := map[string]bool{}
+	for _, lintError := range errors {
+		seen[branchErrorKey(lintError)] = true
+	}
+
+	for _, scenario := range branchScenarios(conditionals) {
+		newVal, scenarioErrors := l.evaluate(compiledTemplate, filename, scenario)
+		if newVal != nil {
+			scenarioErrors = append(scenarioErrors, validate(newVal)...)
+		}
+		for _, lintError := range scenario.withoutExpectedFailures(scenarioErrors, conditionals) {
+			key := branchErrorKey(lintError)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			errors = append(errors, scenario.attribute(lintError))
+		}
+	}
+
+	return errors
+}
+
+// compile parses a template and compiles it with the hooks needed to explore its branches. If the template is not
+// valid yaml, the syntax error is returned instead.
+func compile(data, filename string) (*template.CompiledTemplate, []*conditional, []LinterError) {
 	docSet, err := yamlmeta.NewDocumentSetFromBytes([]byte(data), yamlmeta.DocSetOpts{AssociatedName: filename})
 	if err != nil {
 		msg := err.Error()
@@ -209,7 +297,7 @@ func (l *Linter) lint(data, filename string, autoImport bool) []LinterError {
 		}
 		msg = match[2]
 
-		return []LinterError{{
+		return nil, nil, []LinterError{{
 			Msg: msg,
 			Pos: fmt.Sprintf("%s:%d", filename, line),
 		}}
@@ -217,7 +305,7 @@ func (l *Linter) lint(data, filename string, autoImport bool) []LinterError {
 
 	//fmt.Printf("### ast:\n")
 	//docSet.Print(os.Stdout)
-	injectIfHandling(docSet)
+	conditionals := injectBranchHandling(docSet)
 	injectStringTemplateHandling(docSet)
 	//docSet.Print(os.Stdout)
 
@@ -228,92 +316,91 @@ func (l *Linter) lint(data, filename string, autoImport bool) []LinterError {
 		fmt.Printf("NewTemplate: %s\n", err.Error())
 		os.Exit(1)
 	}
+	injectLintBuiltins(compiledTemplate)
+
+	return compiledTemplate, conditionals, nil
+}
+
+// evaluate renders a compiled template. If scenario is not nil, it decides which branches of conditionals are taken.
+// If the template could be rendered, the returned errors are the ones reported during evaluation, e.g. accesses to
+// undefined data values or files missing for data.read.
+func (l *Linter) evaluate(compiledTemplate *template.CompiledTemplate, filename string, scenario *branchScenario) (*yamlmeta.DocumentSet, []LinterError) {
+	result, errors := l.execute(compiledTemplate, filename, scenario)
+	if result == nil {
+		return nil, errors
+	}
+
+	//fmt.Printf("### result ast:\n")
+	//result.value.(*yamlmeta.DocumentSet).Print(os.Stdout)
+
+	return result.value.(*yamlmeta.DocumentSet), result.usage.errors
+}
 
+// execution is the result of a compiled template, which has been evaluated successfully
+type execution struct {
+	globals starlark.StringDict
+	value   interface{}
+	// thread can be used to call the functions defined by the template
+	thread *starlark.Thread
+	loader myTemplateLoader
+	usage  *dataValuesUsage
+}
+
+// execute evaluates a compiled template, which is either a yaml template or a starlark module. If the evaluation
+// fails, the errors are returned instead.
+func (l *Linter) execute(compiledTemplate *template.CompiledTemplate, filename string, scenario *branchScenario) (*execution, []LinterError) {
 	//fmt.Printf("### template:\n%s\n", compiledTemplate.DebugCodeAsString())
-	loader := myTemplateLoader{compiledTemplate: compiledTemplate, name: filename}
+	modules, libraryCtx, err := l.libraries(filename)
+	if err != nil {
+		return nil, []LinterError{{
+			Msg: fmt.Sprintf("could not read the files of the root folder: %v", err),
+			Pos: fmt.Sprintf("%s:1", filename),
+		}}
+	}
+	loader := myTemplateLoader{compiledTemplate: compiledTemplate, name: filename, modules: modules}
+	usage := &dataValuesUsage{linter: l, compiledTemplate: compiledTemplate, filename: filename}
+	loader.files = l.dataFiles(modules, usage)
+	if l.DataValues != nil {
+		loader.dataValues = usage.asStarlark(l.DataValues.Values, "data.values", l.DataValues.Schema)
+	}
 	loader.TemplateLoader = workspace.NewTemplateLoader(workspace.NewEmptyDataValues(), []*workspace.DataValues{}, core.NewPlainUI(false), workspace.TemplateLoaderOpts{
 		IgnoreUnknownComments: true,
 	}, nil)
-	var rootLib *workspace.Library
-	loader.api, rootLib = newAPIandLib(filename, compiledTemplate.TplReplaceNode, loader)
+	loader.api = newAPI(libraryCtx, compiledTemplate.TplReplaceNode, loader)
 	thread := &starlark.Thread{Name: "test", Load: loader.Load}
+	usage.thread = thread
 
-	thread.SetLocal("ytt.curr_library_key", rootLib)
-	thread.SetLocal("ytt.root_library_key", rootLib)
+	thread.SetLocal("ytt.curr_library_key", libraryCtx.Current)
+	thread.SetLocal("ytt.root_library_key", libraryCtx.Root)
+	thread.SetLocal(branchScenarioKey, scenario)
 
-	_, newVal, err := compiledTemplate.Eval(thread, loader)
+	globals, newVal, err := compiledTemplate.Eval(thread, loader)
 	if err != nil {
-		multiErr, ok := err.(template.CompiledTemplateMultiError)
-		if ok {
-			return mapMultierrorToLinterror(multiErr, filename)
-		}
-		fmt.Printf("Eval: %s\n", err.Error())
-		os.Exit(1)
-
+		return nil, mapEvalError(err, filename)
 	}
 
-	//fmt.Printf("### result ast:\n")
-	//newVal.(*yamlmeta.DocumentSet).Print(os.Stdout)
-
-	//combinedDocBytes, err := newVal.(*yamlmeta.DocumentSet).AsBytesWithPrinter(nil)
-	//if err != nil {
-	//	fmt.Printf(err.Error())
-	//	os.Exit(1)
-	//}
-
-	//fmt.Printf("### result\n")
-	//fmt.Printf("%s\n", combinedDocBytes)
-
-	//schemaBytes, err := newVal.(*yamlmeta.DocumentSet).AsBytesWithPrinter(
-	//	func(w io.Writer) yamlmeta.DocumentPrinter { return &schemaPrinter{buf: w} })
-	//if err != nil {
-	//	fmt.Printf(err.Error())
-	//	os.Exit(1)
-	//}
-
-	//fmt.Printf("### schema\n")
-	//fmt.Printf("%s\n", schemaBytes)
+	return &execution{globals: globals, value: newVal, thread: thread, loader: loader, usage: usage}, nil
+}
 
-	errors := make([]LinterError, 0)
+// magicComparisonRegexp matches errors of ordered comparisons between computed and concrete values, which starlark
+// does not allow types to implement
+var magicComparisonRegexp = regexp.MustCompile(`^(magic \S+ \w+|\w+ \S+ magic) not implemented$`)
 
-	if autoImport {
-		err = importCRDs(filename, newVal.(*yamlmeta.DocumentSet))
-		if err != nil {
-			panic(fmt.Errorf("autoimport failed: %w", err))
-		}
+// mapEvalError maps the errors of an evaluation to the lines of the templates. Comparisons of computed values are
+// replaced in the linted code, but modules loaded by it still fail to compare them, which is not reported.
+func mapEvalError(err error, filename string) []LinterError {
+	multiErr, ok := err.(template.CompiledTemplateMultiError)
+	if !ok {
+		fmt.Printf("Eval: %s\n", err.Error())
+		os.Exit(1)
 	}
 
-	for _, doc := range newVal.(*yamlmeta.DocumentSet).Items {
-		gvk, item := extractKind(doc)
-		var err error
-		var schema *v1.JSONSchemaProps
-		if gvk.kind != "" {
-			schema, err = loadK8SSchema(gvk)
-			if err != nil {
-				errors = append(errors,
-					appendLocationIfKnownf(item, "Error loading schema for kind %s: %v\n", gvk.kind, err.Error()))
-				continue
-			}
-		} else if isConcoursePipeline(doc) {
-			schema, err = loadConcourseSchema()
-			if err != nil {
-				panic(err)
-			}
-		} else {
-			continue
-			// TODO: print warning if not a trivial document
-		}
-
-		subSchema := convert(doc.Value)
-		if subSchema.Description == "" && doc.Position.IsKnown() {
-			doc.Position.SetFile(filename)
-			subSchema.Description = doc.Position.AsString()
+	errors := []LinterError{}
+	for _, lintError := range mapMultierrorToLinterror(multiErr, filename) {
+		if !magicComparisonRegexp.MatchString(lintError.Msg) {
+			errors = append(errors, lintError)
 		}
-
-		subErrors := l.isSubset(schema.Definitions, subSchema, schema, "")
-		errors = append(errors, subErrors...)
 	}
-
 	return errors
 }
 
@@ -346,14 +433,7 @@ func extractKind(doc *yamlmeta.Document) (kubernetesGVK, *yamlmeta.MapItem) {
 			gvk.kind = item.Value.(string)
 			loc = item
 		} else if item.Key == "apiVersion" {
-			gv := strings.SplitN(item.Value.(string), "/", 2)
-			if len(gv) == 1 {
-				gvk.group = "core"
-				gvk.version = gv[0]
-			} else {
-				gvk.group = gv[0]
-				gvk.version = gv[1]
-			}
+			gvk.group, gvk.version = parseAPIVersion(item.Value.(string))
 		}
 	}
 	if gvk.group == "" || gvk.kind == "" || gvk.version == "" {
@@ -390,15 +470,12 @@ func convert(value interface{}) *v1.JSONSchemaProps {
 
 		for _, item := range v.Items {
 			value := convert(item.Value)
-			key := item.Key.(string)
-
-			if strings.HasPrefix(key, "__ytt_lint_t_") || strings.HasPrefix(key, "__ytt_lint_f_") {
-				key = key[13:]
-			}
+			key := fmt.Sprint(item.Key)
 
 			if value.Description == "" && item.Position.IsKnown() {
 				value.Description = item.Position.AsCompactString()
 			}
+			inheritDescription(value)
 
 			_, allreadExists := object.Properties[key]
 			if allreadExists {
@@ -425,6 +502,7 @@ func convert(value interface{}) *v1.JSONSchemaProps {
 			if convertedItem.Description == "" && item.Position.IsKnown() {
 				convertedItem.Description = item.Position.AsCompactString()
 			}
+			inheritDescription(convertedItem)
 
 			items = append(items, *convertedItem)
 		}
@@ -436,6 +514,16 @@ func convert(value interface{}) *v1.JSONSchemaProps {
 			},
 		}
 
+	case *orderedmap.Map: // e.g. a struct or dict returned by an expression
+		object := v1.JSONSchemaProps{
+			Type:       "object",
+			Properties: map[string]v1.JSONSchemaProps{},
+		}
+		v.Iterate(func(key, value interface{}) {
+			object.Properties[fmt.Sprint(key)] = *convert(value)
+		})
+		return &object
+
 	case []interface{}:
 		items := make([]v1.JSONSchemaProps, 0, len(v))
 		for _, item := range v {
@@ -493,6 +581,28 @@ func convert(value interface{}) *v1.JSONSchemaProps {
 				Raw: encoded,
 			},
 		}
+	case float64:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		return &v1.JSONSchemaProps{
+			Type: "number",
+			Default: &v1.JSON{
+				Raw: encoded,
+			},
+		}
+	case float32:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		return &v1.JSONSchemaProps{
+			Type: "number",
+			Default: &v1.JSON{
+				Raw: encoded,
+			},
+		}
 	case bool:
 		encoded, err := json.Marshal(v)
 		if err != nil {
@@ -533,6 +643,26 @@ func convert(value interface{}) *v1.JSONSchemaProps {
 
 }
 
+// inheritDescription passes the location of a value down to its children without a location,
+// e.g. to the entries of a struct returned by an expression
+func inheritDescription(schema *v1.JSONSchemaProps) {
+	for key, prop := range schema.Properties {
+		if prop.Description == "" {
+			prop.Description = schema.Description
+			inheritDescription(&prop)
+			schema.Properties[key] = prop
+		}
+	}
+	if schema.Items != nil {
+		for i := range schema.Items.JSONSchemas {
+			if schema.Items.JSONSchemas[i].Description == "" {
+				schema.Items.JSONSchemas[i].Description = schema.Description
+				inheritDescription(&schema.Items.JSONSchemas[i])
+			}
+		}
+	}
+}
+
 func (l *Linter) isSubset(defs v1.JSONSchemaDefinitions, subSchema, schema *v1.JSONSchemaProps, path string) []LinterError {
 	errors := make([]LinterError, 0)
 
@@ -552,69 +682,60 @@ func (l *Linter) isSubset(defs v1.JSONSchemaDefinitions, subSchema, schema *v1.J
 		}
 	}
 
-	switch schema.Type {
-	case "object":
-		for key, prop := range schema.Properties {
-			if subSchema.Type == "object" {
-
-				subProp, ok := subSchema.Properties[key]
-				if !ok {
-					subPropT, okT := subSchema.Properties["__ytt_lint_t_"+key]
-					if okT {
-						subErrors := l.isSubset(defs, &subPropT, &prop, fmt.Sprintf("%s.%s", path, key))
-						errors = append(errors, subErrors...)
-					}
-					subPropF, okF := subSchema.Properties["__ytt_lint_f_"+key]
-					if okF {
-						subErrors := l.isSubset(defs, &subPropF, &prop, fmt.Sprintf("%s.%s", path, key))
-						errors = append(errors, subErrors...)
-					}
-
-					if !okF || !okT {
-						for _, requiredKey := range schema.Required {
-							if requiredKey == key {
-								errors = append(errors, appendLocationIfKnownf(subSchema, "%s missing required entry %s", path, key))
-								//break
-							}
-						}
-					}
-				} else {
-					subErrors := l.isSubset(defs, &subProp, &prop, fmt.Sprintf("%s.%s", path, key))
-					errors = append(errors, subErrors...)
-				}
-			} else {
-				errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected object got: %s", path, subSchema.Type))
+	if isNull(subSchema) && schema.Nullable {
+		return errors
+	}
+	if subSchema.Type == "magic" {
+		computed := extractMagicTypeFromSchema(subSchema)
+		notNone := computed.Without(magic.MagicType{CouldBeNone: true})
+		if schema.Nullable {
+			if computed.CouldOnlyBe(magic.MagicType{CouldBeNone: true}) {
+				return errors
+			}
+			subSchema = computedSchema(notNone, subSchema.Description)
+		} else if accepted, ok := acceptedMagicType(schema); ok && computed.CouldBeNone && !computed.CouldOnlyBe(magic.MagicType{CouldBeNone: true}) && notNone.CouldOnlyBe(accepted) {
+			// a value of the right type, which might be None (e.g. a nullable data value)
+			if l.Pedantic {
+				errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected %s got a computed value, which might be None`, path, schema.Type))
 			}
+			subSchema = computedSchema(notNone, subSchema.Description)
 		}
+	}
 
-		var additionalPropertiesSchema *v1.JSONSchemaProps
-		additionalPropertiesAllowAll := false
-		if schema.AdditionalProperties != nil {
-			additionalPropertiesSchema = schema.AdditionalProperties.Schema
-			additionalPropertiesAllowAll = schema.AdditionalProperties.Allows && (additionalPropertiesSchema == nil)
-		}
-		if !additionalPropertiesAllowAll {
-			for key, val := range subSchema.Properties {
-				_, ok := schema.Properties[key]
-				if !ok {
-					if additionalPropertiesSchema != nil {
-						subErrors := l.isSubset(defs, &val, additionalPropertiesSchema, fmt.Sprintf("%s.%s", path, key))
-						errors = append(errors, subErrors...)
-					} else {
-						errors = append(errors, generateAdditionalPropertiesError(&val, path, key, schema.Properties))
-					}
-				}
-			}
+	errors = append(errors, l.validateEnum(subSchema, schema, path)...)
+	errors = append(errors, l.validateBounds(subSchema, schema, path)...)
+
+	if schema.XIntOrString {
+		intOrStringErrors := l.validateIntOrString(subSchema, path)
+		if len(intOrStringErrors) > 0 {
+			return append(errors, intOrStringErrors...)
 		}
+	}
+
+	if schema.XEmbeddedResource {
+		errors = append(errors, l.validateEmbeddedResource(subSchema, path)...)
+	}
+
+	errors = append(errors, l.validateCombinators(defs, subSchema, schema, path)...)
+
+	switch schema.Type {
+	case "object":
+		errors = append(errors, l.validateObject(defs, subSchema, schema, path, true)...)
 
 	case "array":
 		if subSchema.Type != "array" {
 			if subSchema.Type == "magic" {
-				if l.Pedantic {
+				computed := extractMagicTypeFromSchema(subSchema)
+				if !computed.CouldBe(magic.MagicType{CouldBeList: true}) {
+					errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected array got: %s", path, l.describeType(subSchema)))
+				} else if computed.CouldOnlyBe(magic.MagicType{CouldBeList: true}) && schema.Items != nil && schema.Items.Schema != nil {
+					itemSchema := computedSchema(computed.Index(0).(*magic.MagicType), subSchema.Description)
+					errors = append(errors, l.isSubset(defs, itemSchema, schema.Items.Schema, path+"[]")...)
+				} else if l.Pedantic {
 					errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected array got a computed value`, path))
 				}
 			} else {
-				errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected array got: %s", path, subSchema.Type))
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected array got: %s", path, l.describeType(subSchema)))
 			}
 		} else {
 			itemsSchema := schema.Items.Schema
@@ -622,6 +743,7 @@ func (l *Linter) isSubset(defs v1.JSONSchemaDefinitions, subSchema, schema *v1.J
 				subErrors := l.isSubset(defs, &item, itemsSchema, fmt.Sprintf("%s[%d]", path, i))
 				errors = append(errors, subErrors...)
 			}
+			errors = append(errors, l.validateListType(subSchema, schema, path)...)
 		}
 
 	case "string":
@@ -636,26 +758,30 @@ func (l *Linter) isSubset(defs v1.JSONSchemaDefinitions, subSchema, schema *v1.J
 					}
 				}
 			}
+			errors = append(errors, l.validateFormat(subSchema, schema, path)...)
 		} else {
 			format := schema.Format
 
 			if format == "int-or-string" {
-				if subSchema.Type == "magic" {
-					magic := extractMagicTypeFromSchema(subSchema)
-					if l.Pedantic && !((magic.CouldBeString || magic.CouldBeInt) && !magic.CouldBeFloat) {
-						errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected int-or-string got a computed value. Tip: use str(...) or int(...) to convert to int or string`, path))
-					}
-				} else if subSchema.Type != "integer" {
-					errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected int-or-string got: %s", path, subSchema.Type))
-				}
+				errors = append(errors, l.validateIntOrString(subSchema, path)...)
+			} else if format == "quantity" && (subSchema.Type == "integer" || subSchema.Type == "number") {
+				// plain numbers are valid quantities
 			} else {
 				if subSchema.Type == "magic" {
-					magic := extractMagicTypeFromSchema(subSchema)
-					if l.Pedantic && !(magic.CouldBeString && !magic.CouldBeInt && !magic.CouldBeFloat) {
+					computed := extractMagicTypeFromSchema(subSchema)
+					accepted := magic.MagicType{CouldBeString: true}
+					if format == "quantity" {
+						accepted = magic.MagicType{CouldBeString: true, CouldBeInt: true, CouldBeFloat: true}
+					}
+					if !computed.CouldBe(accepted) {
+						errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected string got: %s", path, l.describeType(subSchema)))
+					} else if l.Pedantic && isCheckedFormat(format) {
+						errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected string of format %s got a computed value`, path, format))
+					} else if l.Pedantic && !computed.CouldOnlyBe(magic.MagicType{CouldBeString: true}) {
 						errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected string got a computed value. Tip: use str(...) to convert to string`, path))
 					}
 				} else if path != ".metadata.creationTimestamp" { // https://github.com/kubernetes-sigs/controller-tools/issues/402
-					errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected string got: %s", path, subSchema.Type))
+					errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected string got: %s", path, l.describeType(subSchema)))
 				}
 			}
 		}
@@ -663,20 +789,46 @@ func (l *Linter) isSubset(defs v1.JSONSchemaDefinitions, subSchema, schema *v1.J
 	case "integer":
 		if subSchema.Type != "integer" {
 			if subSchema.Type == "magic" {
-				magic := extractMagicTypeFromSchema(subSchema)
-				if l.Pedantic && !(magic.CouldBeInt && !magic.CouldBeString && !magic.CouldBeFloat) {
+				computed := extractMagicTypeFromSchema(subSchema)
+				if !computed.CouldBe(magic.MagicType{CouldBeInt: true}) {
+					errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected integer got: %s", path, l.describeType(subSchema)))
+				} else if l.Pedantic && !computed.CouldOnlyBe(magic.MagicType{CouldBeInt: true}) {
 					errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected integer got a computed value. Tip: use int(...) to convert to int`, path))
 				}
 			} else {
-				errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected integer got: %s", path, subSchema.Type))
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected integer got: %s", path, l.describeType(subSchema)))
+			}
+		}
+	case "number":
+		if subSchema.Type != "number" && subSchema.Type != "integer" {
+			if subSchema.Type == "magic" {
+				computed := extractMagicTypeFromSchema(subSchema)
+				if !computed.CouldBe(magic.MagicType{CouldBeInt: true, CouldBeFloat: true}) {
+					errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected number got: %s", path, l.describeType(subSchema)))
+				} else if l.Pedantic && !computed.CouldOnlyBe(magic.MagicType{CouldBeInt: true, CouldBeFloat: true}) {
+					errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected number got a computed value. Tip: use float(...) to convert to float`, path))
+				}
+			} else {
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected number got: %s", path, l.describeType(subSchema)))
 			}
 		}
 	case "boolean":
-		if subSchema.Type != "boolean" {
-			errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected boolean got: %s", path, subSchema.Type))
+		if subSchema.Type == "magic" {
+			computed := extractMagicTypeFromSchema(subSchema)
+			if !computed.CouldBe(magic.MagicType{CouldBeBool: true}) {
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected boolean got: %s", path, l.describeType(subSchema)))
+			} else if l.Pedantic && !computed.CouldOnlyBe(magic.MagicType{CouldBeBool: true}) {
+				errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected boolean got a computed value. Tip: use bool(...) to convert to bool`, path))
+			}
+		} else if subSchema.Type != "boolean" {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected boolean got: %s", path, l.describeType(subSchema)))
 		}
 
 	case "":
+		// typeless schemas (e.g. branches of combinators) only constrain the properties they mention
+		if subSchema.Type == "object" && (len(schema.Properties) > 0 || len(schema.Required) > 0 || len(schema.PatternProperties) > 0 || schema.AdditionalProperties != nil) {
+			errors = append(errors, l.validateObject(defs, subSchema, schema, path, false)...)
+		}
 
 	default:
 		errors = append(errors, appendLocationIfKnownf(subSchema, " unsupported type %s", schema.Type))
@@ -685,6 +837,90 @@ func (l *Linter) isSubset(defs v1.JSONSchemaDefinitions, subSchema, schema *v1.J
 	return errors
 }
 
+// validateObject checks properties, required entries and additional properties. If strict is false, additional
+// properties are only rejected if the schema explicitly restricts them.
+func (l *Linter) validateObject(defs v1.JSONSchemaDefinitions, subSchema, schema *v1.JSONSchemaProps, path string, strict bool) []LinterError {
+	errors := make([]LinterError, 0)
+
+	if subSchema.Type != "object" {
+		if !strict {
+			return errors
+		}
+		if subSchema.Type == "magic" {
+			if !extractMagicTypeFromSchema(subSchema).CouldBe(magic.MagicType{CouldBeDict: true, CouldBeStruct: true}) {
+				errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected object got: %s", path, l.describeType(subSchema)))
+			} else if l.Pedantic {
+				errors = append(errors, appendLocationIfKnownf(subSchema, `%s expected object got a computed value`, path))
+			}
+		} else {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s expected object got: %s", path, l.describeType(subSchema)))
+		}
+		return errors
+	}
+
+	errors = append(errors, validateKeys(subSchema, path)...)
+
+	for key, prop := range schema.Properties {
+		subProp, ok := subSchema.Properties[key]
+		if !ok {
+			for _, requiredKey := range schema.Required {
+				if requiredKey == key {
+					errors = append(errors, appendLocationIfKnownf(subSchema, "%s missing required entry %s", path, key))
+				}
+			}
+		} else {
+			subErrors := l.isSubset(defs, &subProp, &prop, fmt.Sprintf("%s.%s", path, key))
+			errors = append(errors, subErrors...)
+
+			// an explicit null does not satisfy required, unless the type check already complained about it
+			if len(subErrors) == 0 && isNull(&subProp) && !prop.Nullable {
+				for _, requiredKey := range schema.Required {
+					if requiredKey == key {
+						errors = append(errors, appendLocationIfKnownf(&subProp, "%s.%s required entry must not be %s", path, key, l.describeType(&subProp)))
+					}
+				}
+			}
+		}
+	}
+
+	for _, requiredKey := range schema.Required {
+		_, declared := schema.Properties[requiredKey]
+		_, present := subSchema.Properties[requiredKey]
+		if !declared && !present {
+			errors = append(errors, appendLocationIfKnownf(subSchema, "%s missing required entry %s", path, requiredKey))
+		}
+	}
+
+	var additionalPropertiesSchema *v1.JSONSchemaProps
+	additionalPropertiesAllowAll := !strict
+	if schema.AdditionalProperties != nil {
+		additionalPropertiesSchema = schema.AdditionalProperties.Schema
+		additionalPropertiesAllowAll = schema.AdditionalProperties.Allows && (additionalPropertiesSchema == nil)
+	}
+	if isPreservingUnknownFields(schema) {
+		additionalPropertiesAllowAll = true
+	}
+	for key, val := range subSchema.Properties {
+		_, ok := schema.Properties[key]
+		if ok {
+			continue
+		}
+		matched, subErrors := l.matchPatternProperties(defs, &val, schema, path, key)
+		errors = append(errors, subErrors...)
+		if matched || additionalPropertiesAllowAll {
+			continue
+		}
+		if additionalPropertiesSchema != nil {
+			subErrors := l.isSubset(defs, &val, additionalPropertiesSchema, fmt.Sprintf("%s.%s", path, key))
+			errors = append(errors, subErrors...)
+		} else {
+			errors = append(errors, generateAdditionalPropertiesError(&val, path, key, schema.Properties))
+		}
+	}
+
+	return errors
+}
+
 func extractMagicTypeFromSchema(schema *v1.JSONSchemaProps) *magic.MagicType {
 	magic := &magic.MagicType{}
 	err := json.Unmarshal(schema.Default.Raw, magic)
@@ -694,6 +930,35 @@ func extractMagicTypeFromSchema(schema *v1.JSONSchemaProps) *magic.MagicType {
 	return magic
 }
 
+// acceptedMagicType is the MagicType of all values valid for the type of schema
+func acceptedMagicType(schema *v1.JSONSchemaProps) (magic.MagicType, bool) {
+	if schema.XIntOrString {
+		return magic.MagicType{CouldBeString: true, CouldBeInt: true}, true
+	}
+	switch schema.Type {
+	case "string":
+		return magic.MagicType{CouldBeString: true}, true
+	case "integer":
+		return magic.MagicType{CouldBeInt: true}, true
+	case "number":
+		return magic.MagicType{CouldBeInt: true, CouldBeFloat: true}, true
+	case "boolean":
+		return magic.MagicType{CouldBeBool: true}, true
+	case "array":
+		return magic.MagicType{CouldBeList: true}, true
+	case "object":
+		return magic.MagicType{CouldBeDict: true, CouldBeStruct: true}, true
+	}
+	return magic.MagicType{}, false
+}
+
+// computedSchema is the schema of a value of type computed at the given position
+func computedSchema(computed *magic.MagicType, position string) *v1.JSONSchemaProps {
+	schema := convert(computed)
+	schema.Description = position
+	return schema
+}
+
 func extractStringFromSchema(schema *v1.JSONSchemaProps) string {
 	var data = ""
 	err := json.Unmarshal(schema.Default.Raw, &data)
@@ -734,63 +999,65 @@ func (tcl typoCandidateList) Swap(i, j int) {
 	tcl[i], tcl[j] = tcl[j], tcl[i]
 }
 
+// Less sorts the most similar candidates first, so the best matches are kept when the list is truncated
 func (s typoCandidateList) Less(i, j int) bool {
-	return s[i].similarity < s[j].similarity
+	if s[i].similarity != s[j].similarity {
+		return s[i].similarity > s[j].similarity
+	}
+	return s[i].key < s[j].key
 }
 
-func generateAdditionalPropertiesError(val *v1.JSONSchemaProps, path string, key string, schema map[string]v1.JSONSchemaProps) LinterError {
-	message := fmt.Sprintf("%s.%s additional properties are not permitted", path, key)
-
-	alternatives := []string{}
-	candidates := typoCandidateList{}
+// suggestAlternatives returns up to five candidates which look similar to key, best match first
+func suggestAlternatives(key string, candidates []string) []string {
+	similar := typoCandidateList{}
 
-	for candidate := range schema {
+	for _, candidate := range candidates {
 		similarity := strutil.Similarity(strings.ToLower(key), strings.ToLower(candidate), metrics.NewHamming())
 		if similarity < 0.25 {
 			continue
 		}
-		candidates = append(candidates, typoCandidate{
+		similar = append(similar, typoCandidate{
 			similarity: similarity,
 			key:        candidate,
 		})
 	}
 
-	sort.Sort(candidates)
+	sort.Sort(similar)
 
-	if len(candidates) > 5 {
-		candidates = candidates[:5]
+	if len(similar) > 5 {
+		similar = similar[:5]
 	}
 
-	for _, candidate := range candidates {
+	alternatives := []string{}
+	for _, candidate := range similar {
 		alternatives = append(alternatives, candidate.key)
 	}
+	return alternatives
+}
 
+func generateAdditionalPropertiesError(val *v1.JSONSchemaProps, path string, key string, schema map[string]v1.JSONSchemaProps) LinterError {
+	message := fmt.Sprintf("%s.%s additional properties are not permitted", path, key)
+
+	candidates := make([]string, 0, len(schema))
+	for candidate := range schema {
+		candidates = append(candidates, candidate)
+	}
+
+	alternatives := suggestAlternatives(key, candidates)
 	if len(alternatives) != 0 {
 		message = fmt.Sprintf("%s. Did you mean: %s?", message, strings.Join(alternatives, ", "))
 	}
 
-	return appendLocationIfKnownf(val, message)
+	return appendLocationIfKnownf(val, "%s", message)
 }
 
-func newAPIandLib(filename string, replaceNodeFunc tplcore.StarlarkFunc, loader yttlibrary.DataLoader) (yttlibrary.API, *workspace.Library) {
+func newAPI(libraryCtx workspace.LibraryExecutionContext, replaceNodeFunc tplcore.StarlarkFunc, loader yttlibrary.DataLoader) yttlibrary.API {
 	libraryExecutionFactory := workspace.NewLibraryExecutionFactory(core.NewPlainUI(false), workspace.TemplateLoaderOpts{
 		IgnoreUnknownComments: true,
 	})
 
-	inputFiles, err := files.NewSortedFilesFromPaths([]string{filepath.Dir(filename)}, files.SymlinkAllowOpts{
-		AllowAll: true,
-	})
-	if err != nil {
-		os.Exit(1)
-	}
-	rootLib := workspace.NewRootLibrary(inputFiles)
-	library := workspace.NewLibraryModule(workspace.LibraryExecutionContext{
-		Current: rootLib,
-		Root:    rootLib,
-	}, libraryExecutionFactory, []*workspace.DataValues{})
+	library := workspace.NewLibraryModule(libraryCtx, libraryExecutionFactory, []*workspace.DataValues{})
 	libraryModule := library.AsModule()
 
-	api := yttlibrary.NewAPI(replaceNodeFunc, yttlibrary.NewDataModule(&yamlmeta.Document{}, loader), libraryModule)
-
-	return api, rootLib
+	return yttlibrary.NewAPI(replaceNodeFunc, yttlibrary.NewDataModule(&yamlmeta.Document{}, loader), libraryModule)
 }
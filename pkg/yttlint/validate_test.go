@@ -1,10 +1,13 @@
 package yttlint
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"testing"
 
+	"github.com/k14s/ytt/pkg/yamlmeta"
 	. "github.com/onsi/gomega"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
 
 func TestValidate(t *testing.T) {
@@ -59,9 +62,12 @@ func TestValidate(t *testing.T) {
 	}, {
 		filename: "../../examples/lint/load-not-found.yaml",
 		nonPedanticErrors: []LinterError{{
-			// TODO: might remove the hint as it will confuse extension users.
-			Msg: "cannot load file-not-found.yaml: Expected to find file 'file-not-found.yaml' (hint: only files included via -f flag are available)",
+			Msg: "cannot load file-not-found.yaml: module not found (searched: file-not-found.yaml)",
 			Pos: "test:2",
+		}, {
+			Msg:  "data is loaded but never used",
+			Pos:  "test:1",
+			Code: ErrorCodeUnusedLoad,
 		}},
 		pedanticErrors: []LinterError{},
 	}, {
@@ -85,6 +91,32 @@ func TestValidate(t *testing.T) {
 			Msg: ".spec.imagePullSecrets expected array got a computed value",
 			Pos: "test:10",
 		}},
+	}, {
+		filename: "../../examples/lint/label-keys.yaml",
+		nonPedanticErrors: []LinterError{{
+			Msg: `.metadata.labels invalid key "app name": name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')`,
+			Pos: "test:7",
+		}, {
+			Msg: `.metadata.labels invalid key "app.kubernetes.io/this-name-segment-is-way-too-long-to-be-accepted-as-the-name-of-a-label-key": name part must be no more than 63 bytes`,
+			Pos: "test:8",
+		}, {
+			Msg: `.metadata.annotations invalid key "/owner": prefix part must be non-empty`,
+			Pos: "test:11",
+		}},
+		pedanticErrors: []LinterError{},
+	}, {
+		filename: "../../examples/lint/conditional-documents.yaml",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".spec.containers[0].image expected string got: integer",
+			Pos: "test:13",
+		}, {
+			Msg: ".spec.ports[0].port expected integer got: string",
+			Pos: "test:25",
+		}, {
+			Msg: ".data.key expected string got: integer",
+			Pos: "test:33",
+		}},
+		pedanticErrors: []LinterError{},
 	}, {
 		filename: "../../examples/lint/concourse-caches.yaml",
 		nonPedanticErrors: []LinterError{{
@@ -95,6 +127,95 @@ func TestValidate(t *testing.T) {
 			Pos: "test:18",
 		}},
 		pedanticErrors: []LinterError{},
+	}, {
+		filename: "../../examples/lint/duplicate-containers.yaml",
+		nonPedanticErrors: []LinterError{{
+			Msg: `.spec.containers[0] duplicate entry name="app", also defined by .spec.containers[1] at test:18`,
+			Pos: "test:8",
+		}, {
+			Msg: `.spec.containers[1] duplicate entry name="app", first defined by .spec.containers[0] at test:8`,
+			Pos: "test:18",
+		}, {
+			Msg: `.spec.containers[0].env[0] duplicate entry name="LOG_LEVEL", also defined by .spec.containers[0].env[2] at test:16`,
+			Pos: "test:11",
+		}, {
+			Msg: `.spec.containers[0].env[2] duplicate entry name="LOG_LEVEL", first defined by .spec.containers[0].env[0] at test:11`,
+			Pos: "test:16",
+		}},
+		pedanticErrors: []LinterError{{
+			Msg: ".spec.containers[0].env[1].name expected string got a computed value. Tip: use str(...) to convert to string",
+			Pos: "test:14",
+		}},
+	}, {
+		filename:          "../../examples/lint/computed-objects.yaml",
+		nonPedanticErrors: []LinterError{},
+		pedanticErrors: []LinterError{{
+			Msg: ".metadata expected object got a computed value",
+			Pos: "test:4",
+		}, {
+			Msg: ".data expected object got a computed value",
+			Pos: "test:5",
+		}},
+	}, {
+		filename: "../../examples/lint/comparisons.yaml",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".metadata.namespace expected string got: integer",
+			Pos: "test:7",
+		}, {
+			Msg: ".spec.replicas expected integer got: string",
+			Pos: "test:13",
+		}},
+		pedanticErrors: []LinterError{{
+			Msg: ".spec.replicas expected integer got a computed value. Tip: use int(...) to convert to int",
+			Pos: "test:11",
+		}},
+	}, {
+		filename:          "../../examples/lint/guards.yaml",
+		nonPedanticErrors: []LinterError{},
+		pedanticErrors:    []LinterError{},
+	}, {
+		filename: "../../examples/lint/conditionals.yaml",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".spec.replicas expected integer got: string",
+			Pos: "test:12",
+		}, {
+			Msg: ".spec.selector expected object got: string",
+			Pos: "test:15",
+		}, {
+			Msg: ".spec.template expected object got: string",
+			Pos: "test:20",
+		}, {
+			Msg: ".spec missing required entry selector (when the else branch at test:16 is taken)",
+			Pos: "test:6",
+		}, {
+			Msg: ".spec missing required entry template (when the condition at test:19 is false)",
+			Pos: "test:6",
+		}},
+		pedanticErrors: []LinterError{},
+	}, {
+		filename: "../../examples/lint/library-functions.yaml",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".spec.replicas expected integer got: computed string",
+			Pos: "test:22",
+		}},
+		pedanticErrors: []LinterError{{
+			Msg: ".spec.minReadySeconds expected integer got a computed value. Tip: use int(...) to convert to int",
+			Pos: "test:23",
+		}},
+	}, {
+		filename: "../../examples/lint/library-function-arguments.yaml",
+		nonPedanticErrors: []LinterError{{
+			Msg: "md5.sum: expected starlark.String, but was *starlark.Builtin",
+			Pos: "test:10",
+		}},
+		pedanticErrors: []LinterError{},
+	}, {
+		filename: "../../examples/lint/failures.yaml",
+		nonPedanticErrors: []LinterError{{
+			Msg: "assert.fail: fail: not supported",
+			Pos: "test:2",
+		}},
+		pedanticErrors: []LinterError{},
 	}, {
 		filename:          "../../examples/lint/array-access.yaml",
 		nonPedanticErrors: []LinterError{},
@@ -129,3 +250,131 @@ func TestValidate(t *testing.T) {
 
 	}
 }
+
+func TestGenerateAdditionalPropertiesError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	properties := map[string]v1.JSONSchemaProps{}
+	for _, key := range []string{"name", "names", "named", "namespace", "nameprefix", "game", "same", "lame", "label"} {
+		properties[key] = v1.JSONSchemaProps{}
+	}
+
+	err := generateAdditionalPropertiesError(&v1.JSONSchemaProps{Description: "test:1"}, ".metadata", "nmae", properties)
+	g.Expect(err.Msg).To(Equal(".metadata.nmae additional properties are not permitted. Did you mean: name, named, names, game, lame?"))
+}
+
+func TestNumbers(t *testing.T) {
+	runSchemaTests(t, []schemaTest{{
+		name: "numbers",
+		schema: `{"type": "object", "properties": {
+			"threshold": {"type": "number", "minimum": 0, "maximum": 1},
+			"ratio": {"type": "number"},
+			"weight": {"type": "number", "multipleOf": 0.5},
+			"replicas": {"type": "integer"},
+			"name": {"type": "string"},
+			"computed": {"type": "number"},
+			"computedString": {"type": "number"},
+			"invalid": {"type": "number"}
+		}}`,
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"threshold: 1.5\n" +
+			"ratio: 3\n" +
+			"weight: 1.5\n" +
+			"replicas: 1.5\n" +
+			"name: 0.5\n" +
+			"computed: #@ data.values.ratio / 2\n" +
+			"computedString: #@ data.values.ratio + \"%\"\n" +
+			"invalid: \"0.5\"\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".threshold invalid value 1.5. Must be less than or equal to 1",
+			Pos: "test:2",
+		}, {
+			Msg: ".replicas expected integer got: number",
+			Pos: "test:5",
+		}, {
+			Msg: ".name expected string got: number",
+			Pos: "test:6",
+		}, {
+			Msg: ".computedString expected number got: computed string",
+			Pos: "test:8",
+		}, {
+			Msg: ".invalid expected number got: string",
+			Pos: "test:9",
+		}},
+		pedanticErrors: []LinterError{},
+	}})
+}
+
+// schemaTest lints a template against a json schema, which may refer to the given k8s schemas, once in non-pedantic
+// and once in pedantic mode
+type schemaTest struct {
+	name              string
+	schema            string
+	schemas           map[string]string
+	document          string
+	dataValues        string
+	nonPedanticErrors []LinterError
+	pedanticErrors    []LinterError
+}
+
+func runSchemaTests(t *testing.T, cases []schemaTest) {
+	for _, testCase := range cases {
+
+		t.Run(testCase.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			if schemaCache == nil {
+				schemaCache = make(map[string]*v1.JSONSchemaProps)
+			}
+			for key, raw := range testCase.schemas {
+				cached := &v1.JSONSchemaProps{}
+				err := json.Unmarshal([]byte(raw), cached)
+				if err != nil {
+					t.Fatalf("Could not parse schema %s %v", key, err)
+				}
+				schemaCache[key] = cached
+				defer delete(schemaCache, key)
+			}
+
+			var dataValues *DataValues
+			if testCase.dataValues != "" {
+				dataValues = inlineDataValues(g, testCase.dataValues)
+			}
+
+			linter := &Linter{
+				Pedantic:   false,
+				DataValues: dataValues,
+			}
+			errors := linter.lintAgainstSchema(testCase.document, "test", testCase.schema)
+			g.Expect(errors).To(ConsistOf(testCase.nonPedanticErrors))
+
+			linter = &Linter{
+				Pedantic:   true,
+				DataValues: dataValues,
+			}
+			errors = linter.lintAgainstSchema(testCase.document, "test", testCase.schema)
+			g.Expect(errors).To(ConsistOf(append(testCase.nonPedanticErrors, testCase.pedanticErrors...)))
+		})
+
+	}
+}
+
+// lintAgainstSchema validates every document of a template against the given json schema
+func (l *Linter) lintAgainstSchema(data, filename string, rawSchema string) []LinterError {
+	schema := &v1.JSONSchemaProps{}
+	err := json.Unmarshal([]byte(rawSchema), schema)
+	if err != nil {
+		panic(err)
+	}
+
+	return l.lintBranches(data, filename, func(docSet *yamlmeta.DocumentSet) []LinterError {
+		errors := make([]LinterError, 0)
+		for _, doc := range docSet.Items {
+			if doc.Value == nil {
+				continue // trailing comments end up in an empty document
+			}
+			errors = append(errors, l.validateDocument(doc, filename, schema)...)
+		}
+		return errors
+	})
+}
@@ -0,0 +1,201 @@
+package yttlint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/k14s/ytt/pkg/yamlmeta"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apiserver/pkg/cel/environment"
+)
+
+// validateRules evaluates the x-kubernetes-validations of schema against every node of the document. Rules which
+// depend on computed values are skipped.
+func (l *Linter) validateRules(doc *yamlmeta.Document, schema *v1.JSONSchemaProps) []LinterError {
+	return l.walkRules(schema.Definitions, schema, doc.Value, doc.Position.AsCompactString(), "")
+}
+
+func (l *Linter) walkRules(defs v1.JSONSchemaDefinitions, schema *v1.JSONSchemaProps, value interface{}, pos, path string) []LinterError {
+	errors := make([]LinterError, 0)
+
+	if schema == nil {
+		return errors
+	}
+	if schema.Ref != nil && strings.HasPrefix(*schema.Ref, "#/definitions/") {
+		if def, ok := defs[(*schema.Ref)[14:]]; ok {
+			return l.walkRules(defs, &def, value, pos, path)
+		}
+		return errors
+	}
+
+	if len(schema.XValidations) > 0 {
+		self, unknowns := celValue(value)
+		for _, rule := range schema.XValidations {
+			result, err := evalRule(rule.Rule, self, unknowns)
+			if err != nil {
+				if l.Pedantic {
+					errors = append(errors, LinterError{
+						Msg: strings.TrimSpace(fmt.Sprintf("%s could not evaluate rule %q: %v", path, rule.Rule, err)),
+						Pos: pos,
+					})
+				}
+				continue
+			}
+			if result != types.False {
+				continue
+			}
+			message := rule.Message
+			if message == "" {
+				message = fmt.Sprintf("failed rule: %s", rule.Rule)
+			}
+			errors = append(errors, LinterError{
+				Msg: strings.TrimSpace(fmt.Sprintf("%s %s", path, message)),
+				Pos: pos,
+			})
+		}
+	}
+
+	switch typedValue := value.(type) {
+	case *yamlmeta.Map:
+		for _, item := range typedValue.Items {
+			key := fmt.Sprint(item.Key)
+			var prop *v1.JSONSchemaProps
+			if propSchema, ok := schema.Properties[key]; ok {
+				prop = &propSchema
+			} else if schema.AdditionalProperties != nil {
+				prop = schema.AdditionalProperties.Schema
+			}
+			errors = append(errors, l.walkRules(defs, prop, item.Value, item.Position.AsCompactString(), fmt.Sprintf("%s.%s", path, key))...)
+		}
+	case *yamlmeta.Array:
+		if schema.Items == nil {
+			break
+		}
+		for i, item := range typedValue.Items {
+			errors = append(errors, l.walkRules(defs, schema.Items.Schema, item.Value, item.Position.AsCompactString(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+
+	return errors
+}
+
+var ruleEnv *cel.Env
+var ruleProgramCache map[string]cel.Program
+
+// ruleProgram compiles a rule in the environment the Kubernetes API server evaluates x-kubernetes-validations in.
+// self and oldSelf are dynamically typed, as the linter does not derive CEL types from the schema.
+func ruleProgram(rule string) (cel.Program, error) {
+	if program, ok := ruleProgramCache[rule]; ok {
+		return program, nil
+	}
+
+	if ruleEnv == nil {
+		envSet, err := environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion()).Extend(environment.VersionedOptions{
+			IntroducedVersion: version.MajorMinor(1, 0),
+			EnvOptions: []cel.EnvOption{
+				cel.Variable("self", cel.DynType),
+				cel.Variable("oldSelf", cel.DynType),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// rules of installed CRDs are evaluated, so every library of the API server is available
+		ruleEnv = envSet.StoredExpressionsEnv()
+	}
+
+	ast, issues := ruleEnv.Compile(rule)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	program, err := ruleEnv.Program(ast, cel.EvalOptions(cel.OptPartialEval))
+	if err != nil {
+		return nil, err
+	}
+
+	if ruleProgramCache == nil {
+		ruleProgramCache = make(map[string]cel.Program)
+	}
+	ruleProgramCache[rule] = program
+	return program, nil
+}
+
+// evalRule evaluates a rule with the given value of self. The result is unknown if it depends on one of the unknown
+// attributes or on oldSelf, which is only set when an object is updated.
+func evalRule(rule string, self interface{}, unknowns []*cel.AttributePatternType) (ref.Val, error) {
+	program, err := ruleProgram(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := cel.PartialVars(map[string]interface{}{"self": self}, append(unknowns, cel.AttributePattern("oldSelf"))...)
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := program.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := result.(types.Bool); !ok && !types.IsUnknown(result) {
+		return nil, fmt.Errorf("expected a bool, got %s", result.Type().TypeName())
+	}
+	return result, nil
+}
+
+// celValue converts an evaluated node at path (a list of string keys and int64 indexes) below self to a cel value.
+// Computed values are returned as unknown attribute patterns.
+func celValue(value interface{}, path ...interface{}) (interface{}, []*cel.AttributePatternType) {
+	switch typedValue := value.(type) {
+	case *yamlmeta.Map:
+		m := map[string]interface{}{}
+		unknowns := []*cel.AttributePatternType{}
+		for _, item := range typedValue.Items {
+			key := fmt.Sprint(item.Key)
+			if _, exists := m[key]; exists {
+				// key is set in multiple branches of a conditional
+				unknowns = append(unknowns, unknownAttribute(append(path[:len(path):len(path)], key)))
+				continue
+			}
+			itemValue, itemUnknowns := celValue(item.Value, append(path[:len(path):len(path)], key)...)
+			m[key] = itemValue
+			unknowns = append(unknowns, itemUnknowns...)
+		}
+		return m, unknowns
+	case *yamlmeta.Array:
+		items := make([]interface{}, 0, len(typedValue.Items))
+		unknowns := []*cel.AttributePatternType{}
+		for i, item := range typedValue.Items {
+			itemValue, itemUnknowns := celValue(item.Value, append(path[:len(path):len(path)], int64(i))...)
+			items = append(items, itemValue)
+			unknowns = append(unknowns, itemUnknowns...)
+		}
+		return items, unknowns
+	case int:
+		return int64(typedValue), nil
+	case int32:
+		return int64(typedValue), nil
+	case int64, float64, string, bool, nil:
+		return typedValue, nil
+	default:
+		// e.g. *magic.MagicType
+		return nil, []*cel.AttributePatternType{unknownAttribute(path)}
+	}
+}
+
+// unknownAttribute returns the attribute pattern of path below self
+func unknownAttribute(path []interface{}) *cel.AttributePatternType {
+	attribute := cel.AttributePattern("self")
+	for _, qualifier := range path {
+		switch typedQualifier := qualifier.(type) {
+		case string:
+			attribute = attribute.QualString(typedQualifier)
+		case int64:
+			attribute = attribute.QualInt(typedQualifier)
+		}
+	}
+	return attribute
+}
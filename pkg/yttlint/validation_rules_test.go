@@ -0,0 +1,114 @@
+package yttlint
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidationRules(t *testing.T) {
+	type test struct {
+		name              string
+		rules             string
+		document          string
+		nonPedanticErrors []LinterError
+		pedanticErrors    []LinterError
+	}
+
+	cases := []test{{
+		name:  "failed rules",
+		rules: `[{"rule": "self.minReplicas <= self.maxReplicas", "message": "minReplicas must not exceed maxReplicas"}, {"rule": "self.mode != 'legacy'"}]`,
+		document: "spec:\n" +
+			"  minReplicas: 3\n" +
+			"  maxReplicas: 2\n" +
+			"  mode: legacy\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".spec minReplicas must not exceed maxReplicas",
+			Pos: "test:1",
+		}, {
+			Msg: ".spec failed rule: self.mode != 'legacy'",
+			Pos: "test:1",
+		}},
+		pedanticErrors: []LinterError{},
+	}, {
+		name:  "computed values",
+		rules: `[{"rule": "self.minReplicas <= self.maxReplicas"}, {"rule": "self.ports.all(p, p.port > 0)"}, {"rule": "self.name.startsWith('my-')"}]`,
+		document: "#@ load(\"@ytt:data\", \"data\")\n" +
+			"spec:\n" +
+			"  minReplicas: 3\n" +
+			"  maxReplicas: #@ data.values.max\n" +
+			"  name: my-app\n" +
+			"  ports:\n" +
+			"  - port: 80\n" +
+			"  - port: #@ data.values.port\n",
+		nonPedanticErrors: []LinterError{},
+		pedanticErrors:    []LinterError{},
+	}, {
+		name:  "short-circuit evaluation",
+		rules: `[{"rule": "self.ports.exists(p, p.port == 80)"}, {"rule": "self.ports.all(p, p.port > 80)"}, {"rule": "!has(self.maxReplicas) || self.minReplicas <= self.maxReplicas"}]`,
+		document: "spec:\n" +
+			"  ports:\n" +
+			"  - port: 80\n" +
+			"  - name: missing-port\n" +
+			"  minReplicas: 2\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".spec failed rule: self.ports.all(p, p.port > 80)",
+			Pos: "test:1",
+		}},
+		pedanticErrors: []LinterError{},
+	}, {
+		name:  "kubernetes libraries",
+		rules: `[{"rule": "isURL(self.url) && url(self.url).getScheme() == 'https'"}, {"rule": "quantity(self.memory).isLessThan(quantity('1Gi'))"}, {"rule": "self.names.map(n, n.lowerAscii()) == ['a', 'b']"}]`,
+		document: "spec:\n" +
+			"  url: http://example.com\n" +
+			"  memory: 2Gi\n" +
+			"  names: [A, B]\n",
+		nonPedanticErrors: []LinterError{{
+			Msg: ".spec failed rule: isURL(self.url) && url(self.url).getScheme() == 'https'",
+			Pos: "test:1",
+		}, {
+			Msg: ".spec failed rule: quantity(self.memory).isLessThan(quantity('1Gi'))",
+			Pos: "test:1",
+		}},
+		pedanticErrors: []LinterError{},
+	}, {
+		name:  "transition rules",
+		rules: `[{"rule": "self.name == oldSelf.name", "message": "name is immutable"}]`,
+		document: "spec:\n" +
+			"  name: my-app\n",
+		nonPedanticErrors: []LinterError{},
+		pedanticErrors:    []LinterError{},
+	}, {
+		name:  "unsupported rules",
+		rules: `[{"rule": "self.replicas > 1"}, {"rule": "self.name +"}, {"rule": "self.name"}]`,
+		document: "spec:\n" +
+			"  name: my-app\n",
+		nonPedanticErrors: []LinterError{},
+		pedanticErrors: []LinterError{{
+			Msg: ".spec could not evaluate rule \"self.replicas > 1\": no such key: replicas",
+			Pos: "test:1",
+		}, {
+			Msg: ".spec could not evaluate rule \"self.name +\": ERROR: <input>:1:12: Syntax error: mismatched input '<EOF>' expecting {'[', '{', '(', '.', '-', '!', 'true', 'false', 'null', NUM_FLOAT, NUM_INT, NUM_UINT, STRING, BYTES, IDENTIFIER}\n | self.name +\n | ...........^",
+			Pos: "test:1",
+		}, {
+			Msg: ".spec could not evaluate rule \"self.name\": expected a bool, got string",
+			Pos: "test:1",
+		}},
+	}}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			schema := `{"type": "object", "properties": {"spec": {"type": "object", "x-kubernetes-preserve-unknown-fields": true, "x-kubernetes-validations": ` + testCase.rules + `}}}`
+
+			linter := &Linter{Pedantic: false}
+			errors := linter.lintAgainstSchema(testCase.document, "test", schema)
+			g.Expect(errors).To(ConsistOf(testCase.nonPedanticErrors))
+
+			linter = &Linter{Pedantic: true}
+			errors = linter.lintAgainstSchema(testCase.document, "test", schema)
+			g.Expect(errors).To(ConsistOf(append(testCase.nonPedanticErrors, testCase.pedanticErrors...)))
+		})
+	}
+}
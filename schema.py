@@ -33,11 +33,27 @@ def findRefs(o):
 
 label_regex = r'(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?'
 
+def addListTypes(o):
+    """translates strategic merge patch keys into x-kubernetes-list-type, so duplicate entries can be detected"""
+    if isinstance(o, dict):
+        strategy = o.get("x-kubernetes-patch-strategy", "").split(",")
+        if "x-kubernetes-patch-merge-key" in o and "merge" in strategy and "x-kubernetes-list-type" not in o:
+            o["x-kubernetes-list-type"] = "map"
+            o["x-kubernetes-list-map-keys"] = [o["x-kubernetes-patch-merge-key"]]
+        for val in o.values():
+            addListTypes(val)
+
+    if isinstance(o, list):
+        for val in o:
+            addListTypes(val)
+
 def extraceSchema(file):
     schema = json.load(open(file))
     definitions = schema["definitions"]
 
     definitions["io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"]["properties"]["labels"]["additionalProperties"]["pattern"] = label_regex
+    definitions["io.k8s.apimachinery.pkg.api.resource.Quantity"]["format"] = "quantity"
+    addListTypes(definitions)
 
     for (name, root) in definitions.items():
         if "x-kubernetes-group-version-kind" not in root: